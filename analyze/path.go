@@ -2,6 +2,8 @@
 package analyze
 
 import (
+	"greenjade/config"
+
 	"github.com/yourbasic/graph"
 )
 
@@ -11,41 +13,211 @@ const (
 	PitTrapPoint   = 2 // labyrinth level essence - kind of trap
 	ArrowTrapPoint = 3 // labyrinth level essence - kind of trap
 	HeroPoint      = 4 // labyrinth level essence - hero marker
+	ExitPoint      = 5 // labyrinth level essence - explicit exit marker
 )
 
 // convert labyrinth level data from request into graph, set of edges.
-// return length of minimal survivable path.
-func MinSurvivablePathLen(labyrinthData [][]int) (mspLength int) {
+// return length of minimal survivable path or error if level can't be solved.
+func MinSurvivablePathLen(labyrinthData [][]int, constraints config.ConstraintsType) (mspLength int, status error) {
+	var (
+		result ResultType
+	)
+
+	result, status = Analyze(labyrinthData, constraints)
+	if status != nil {
+		return -1, status
+	}
+
+	return result.Length, status
+}
+
+// convert labyrinth level data from request into graph, set of edges.
+// return ordered list of [row, column] points from hero to exit or error if level can't be solved.
+func MinSurvivablePath(labyrinthData [][]int, constraints config.ConstraintsType) (path [][2]int, status error) {
+	var (
+		result ResultType
+	)
+
+	result, status = Analyze(labyrinthData, constraints)
+	if status != nil {
+		return nil, status
+	}
+
+	return result.Path, status
+}
+
+// find hero and exits in labyrinth level data, check that any exit is reachable and find minimal survivable path
+// to the nearest exit. exits are cells marked by ExitPoint, or open border cells if there is no such marker.
+// return result of analysis, and status as error if level can't be solved (nil otherwise)
+func Analyze(labyrinthData [][]int, constraints config.ConstraintsType) (result ResultType, status error) {
 	var (
-		model    map[int]map[int]int
+		vertices map[int]map[int]int
+		points   map[int][2]int
 		lastNode int
+		heroes   int
+		health   int
+		exits    []int
 
-		edges       *graph.Mutable
-		edgesSorted *graph.Immutable
+		edges *graph.Mutable
 
-		dist []int
+		visited      []bool
+		dist, parent []int
+
+		exitState int
 	)
 
-	// convert input data into graph object
-	model, lastNode = buildGraph(labyrinthData)
-	edges = buildEdges(model)
-	edgesSorted = graph.Sort(edges)
+	health = heroHealth(constraints)
 
-	// calculate distance between vertices
-	dist = make([]int, edgesSorted.Order())
-	graph.BFS(edgesSorted, 0, func(v, w int, _ int64) {
-		dist[w] = dist[v] + 1
+	vertices, lastNode, heroes = buildGraph(labyrinthData)
+	points = buildPoints(vertices)
+	exits = buildExits(labyrinthData, vertices)
+	result.Traps, result.OpenTiles = countPoints(labyrinthData)
+
+	// level must have exactly one hero
+	switch {
+	case heroes == 0:
+		result.Status = StatusNoHero
+	case heroes > 1:
+		result.Status = StatusMultipleHeroes
+	}
+
+	if result.Status != StatusSolved {
+		return result, result.Status
+	}
+
+	edges = buildEdges(vertices, lastNode+1)
+
+	// area which hero can visit, whatever traps are on the way
+	visited = searchReachable(edges)
+	for _, ok := range visited {
+		if ok {
+			result.ReachableArea++
+		}
+	}
+
+	// level must have at least one exit
+	if len(exits) == 0 {
+		result.Status = StatusNoExit
+		return result, result.Status
+	}
+
+	// check that there is any route to any exit, whatever traps are on it
+	result.Status = StatusExitUnreachable
+	for _, exit := range exits {
+		if visited[exit] {
+			result.Status = StatusSolved
+		}
+	}
+
+	if result.Status != StatusSolved {
+		return result, result.Status
+	}
+
+	dist, parent = searchPath(edges, lastNode+1, buildDamage(labyrinthData, vertices, constraints), health)
+
+	// calculate distance to every exit and choose the nearest one
+	exitState = -1
+	result.Exits = make([]ExitType, len(exits))
+	for i, exit := range exits {
+		var (
+			state int
+		)
+
+		state = nearestState(dist, exit, health)
+
+		result.Exits[i] = ExitType{Point: points[exit], Length: -1}
+		if state == -1 {
+			continue
+		}
+
+		result.Exits[i].Length = dist[state]
+		if (exitState == -1) || (dist[state] < dist[exitState]) {
+			exitState = state
+		}
+	}
+
+	// exits were not visited by search, so every route is lethal
+	if exitState == -1 {
+		result.Status = StatusNoSurvivablePath
+		return result, result.Status
+	}
+
+	// walk back from exit to hero by parent states
+	result.Exit = points[exitState/(health+1)]
+	result.Length = dist[exitState]
+	result.Path = make([][2]int, dist[exitState]+1)
+	for state, step := exitState, dist[exitState]; step > -1; state, step = parent[state], step-1 {
+		result.Path[step] = points[state/(health+1)]
+	}
+
+	return result, nil
+}
+
+// walk over graph of vertices by BFS from hero vertex.
+// return visited marks for each vertex
+func searchReachable(edges *graph.Mutable) (visited []bool) {
+	visited = make([]bool, edges.Order())
+	visited[0] = true
+
+	graph.BFS(edges, 0, func(_, w int, _ int64) {
+		visited[w] = true
 	})
 
-	// get length for minimal survivable path
-	mspLength = dist[lastNode]
+	return visited
+}
+
+// exit may be reached with any health, choose the nearest state of vertex.
+// return state number or -1 if vertex was not visited
+func nearestState(dist []int, vertex, health int) (nearest int) {
+	nearest = -1
+
+	for hp := 1; hp <= health; hp++ {
+		var (
+			state int
+		)
+
+		state = buildState(vertex, hp, health)
+		if (dist[state] != -1) && ((nearest == -1) || (dist[state] < dist[nearest])) {
+			nearest = state
+		}
+	}
+
+	return nearest
+}
+
+// expand graph of vertices into graph of hero states and walk over it by BFS from hero vertex with full health.
+// return distance and parent state for each state (-1 if not visited)
+func searchPath(edges *graph.Mutable, order int, damage map[int]int, health int) (dist, parent []int) {
+	var (
+		start int
+
+		statesSorted *graph.Immutable
+	)
+
+	statesSorted = graph.Sort(buildStates(edges, order, damage, health))
+
+	// calculate distance between states and remember where we came from
+	dist = make([]int, statesSorted.Order())
+	parent = make([]int, statesSorted.Order())
+	for state := range dist {
+		dist[state] = -1
+		parent[state] = -1
+	}
+
+	start = buildState(0, health, health)
+	dist[start] = 0
+
+	graph.BFS(statesSorted, start, func(v, w int, _ int64) {
+		dist[w] = dist[v] + 1
+		parent[w] = v
+	})
 
-	return mspLength
+	return dist, parent
 }
 
 // numerated vertices of input labyrinth level data
-// return generated object and number top vertex
-func buildGraph(labyrinthData [][]int) (vertices map[int]map[int]int, lastNode int) {
+// return generated object, number top vertex and count of hero markers
+func buildGraph(labyrinthData [][]int) (vertices map[int]map[int]int, lastNode int, heroes int) {
 	var (
 		num int
 	)
@@ -62,10 +234,11 @@ func buildGraph(labyrinthData [][]int) (vertices map[int]map[int]int, lastNode i
 		line = make(map[int]int)
 
 		// loop each line and numerating vertices
-		for x := 0; x <= len(labyrinthData[0])-1; x++ {
+		for x := 0; x <= len(labyrinthData[y])-1; x++ {
 			if labyrinthData[y][x] == HeroPoint {
 				// hero position is always start position
 				line[x] = 0
+				heroes++
 			} else {
 				if labyrinthData[y][x] == WallPoint {
 					// marking wall for don't use on next step
@@ -81,50 +254,157 @@ func buildGraph(labyrinthData [][]int) (vertices map[int]map[int]int, lastNode i
 		vertices[y] = line
 	}
 
-	return vertices, num
+	return vertices, num, heroes
 }
 
-// convert numerated vertices into graph object.
+// convert numerated vertices into graph object, each vertex is linked with right and bottom neighbours.
 // return graph object
-func buildEdges(vertices map[int]map[int]int) (model *graph.Mutable) {
-	var (
-		height, width int
-	)
-
-	// physical size of labyrinth level
-	height = len(vertices)
-	width = len(vertices[0])
-
-	model = graph.New(height * width)
+func buildEdges(vertices map[int]map[int]int, order int) (model *graph.Mutable) {
+	model = graph.New(order)
 
-	// loop in backward order labyrinth level's lines
-	for y := height - 1; y > 0; y-- {
-		// loop each line and numerating vertices
-		for x := 0; x <= (width-1)-1; x++ {
+	for y, line := range vertices {
+		for x, currentVertex := range line {
 			var (
-				currentVertex, upVertex, leftVertex int
+				rightVertex, downVertex int
+				ok                      bool
 			)
 
-			currentVertex = vertices[y][x]
-			leftVertex = vertices[y][x+1]
-			upVertex = vertices[y-1][x]
-
 			// do nothing if current essence is wall
 			if currentVertex == -1 {
 				continue
 			}
 
-			// add edge if right essence is not wall
-			if leftVertex != -1 {
-				model.AddBoth(currentVertex, leftVertex)
+			// add edge if right essence exists and is not wall
+			rightVertex, ok = vertices[y][x+1]
+			if ok && (rightVertex != -1) {
+				model.AddBoth(currentVertex, rightVertex)
+			}
+
+			// add edge if down essence exists and is not wall
+			downVertex, ok = vertices[y+1][x]
+			if ok && (downVertex != -1) {
+				model.AddBoth(currentVertex, downVertex)
+			}
+		}
+	}
+
+	return model
+}
+
+// collect exits of level, see FindExits.
+// return vertex numbers of exits ordered by rows and columns
+func buildExits(labyrinthData [][]int, vertices map[int]map[int]int) (exits []int) {
+	for _, exit := range FindExits(labyrinthData) {
+		exits = append(exits, vertices[exit[0]][exit[1]])
+	}
+
+	return exits
+}
+
+// reverse mapping for numerated vertices.
+// return [row, column] point for each vertex number
+func buildPoints(vertices map[int]map[int]int) (points map[int][2]int) {
+	points = make(map[int][2]int)
+
+	for y, line := range vertices {
+		for x, vertex := range line {
+			// walls have no vertex number
+			if vertex == -1 {
+				continue
 			}
 
-			// add edge if up essence is not wall
-			if upVertex != -1 {
-				model.AddBoth(currentVertex, upVertex)
+			points[vertex] = [2]int{y, x}
+		}
+	}
+
+	return points
+}
+
+// collect damage which hero gets stepping into vertex. damage for each kind of trap specify in config file.
+// return damage for each vertex number
+func buildDamage(labyrinthData [][]int, vertices map[int]map[int]int, constraints config.ConstraintsType) (damage map[int]int) {
+	var (
+		pit, arrow int
+	)
+
+	damage = make(map[int]int)
+	pit, arrow = constraints.Damage.Values()
+
+	for y, line := range vertices {
+		for x, vertex := range line {
+			switch labyrinthData[y][x] {
+			case PitTrapPoint:
+				damage[vertex] = pit
+			case ArrowTrapPoint:
+				damage[vertex] = arrow
 			}
 		}
 	}
 
+	return damage
+}
+
+// expand graph of level's vertices into graph of hero states, where state is pair of vertex and hero's health.
+// hero can step into next vertex only if hero survives after damage of this vertex.
+// return graph object
+func buildStates(edges *graph.Mutable, order int, damage map[int]int, health int) (model *graph.Mutable) {
+	model = graph.New(order * (health + 1))
+
+	for v := 0; v < order; v++ {
+		edges.Visit(v, func(w int, _ int64) (skip bool) {
+			for hp := 1; hp <= health; hp++ {
+				var (
+					rest int
+				)
+
+				// step is lethal, so there is no such edge
+				rest = hp - damage[w]
+				if rest < 1 {
+					continue
+				}
+
+				if rest > health {
+					rest = health
+				}
+
+				model.Add(buildState(v, hp, health), buildState(w, rest, health))
+			}
+
+			return false
+		})
+	}
+
 	return model
 }
+
+// return state number for vertex and current hero's health
+func buildState(vertex, hp, health int) int {
+	return vertex*(health+1) + hp
+}
+
+// starting hero's health specify in config file, but hero always alive at start.
+// return hero's health
+func heroHealth(constraints config.ConstraintsType) int {
+	if constraints.Hero.Health < 1 {
+		return 1
+	}
+
+	return constraints.Hero.Health
+}
+
+// count traps and open tiles of labyrinth level data.
+// return count of traps (of any kind) and count of open tiles
+func countPoints(labyrinthData [][]int) (traps, openTiles int) {
+	for _, line := range labyrinthData {
+		for _, value := range line {
+			switch value {
+			case PitTrapPoint, ArrowTrapPoint:
+				traps++
+			case OpenTilePoint:
+				openTiles++
+			}
+		}
+	}
+
+	return traps, openTiles
+}
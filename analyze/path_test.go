@@ -0,0 +1,249 @@
+package analyze
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"greenjade/config"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func fetchLevelData(t *testing.T, pathToJson string) (labyrinthData [][]int, status error) {
+	var (
+		err      error
+		jsonFile *os.File
+		jsonData []byte
+
+		level struct {
+			Data [][]int
+		}
+	)
+
+	jsonFile, err = os.Open(pathToJson)
+	if err != nil {
+		return nil, errors.New(fmt.Sprint("[error] can't open file:", pathToJson))
+	}
+
+	defer func() {
+		if err = jsonFile.Close(); err != nil {
+			fmt.Println("[error] clear memory file")
+		}
+	}()
+
+	jsonData, err = ioutil.ReadAll(jsonFile)
+	if err != nil {
+		return nil, errors.New("[error] read json data")
+	}
+
+	err = json.Unmarshal(jsonData, &level)
+	if err != nil {
+		return nil, errors.New("[error] unmarshal json data")
+	}
+
+	return level.Data, status
+}
+
+func buildConstraints(health, pit, arrow int) (constraints config.ConstraintsType) {
+	constraints.Hero.Health = health
+	constraints.Damage.Pit = &pit
+	constraints.Damage.Arrow = &arrow
+
+	return constraints
+}
+
+func TestMinSurvivablePath(t *testing.T) {
+	var (
+		err, status error
+
+		labyrinthData [][]int
+		path          [][2]int
+	)
+
+	labyrinthData, err = fetchLevelData(t, "../testdata/data_all_ok_2_msp_12.json")
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	path, status = MinSurvivablePath(labyrinthData, buildConstraints(3, 1, 1))
+	if status != nil {
+		t.Error(status.Error())
+		t.FailNow()
+	}
+
+	if len(path)-1 != 12 {
+		t.Errorf("expected msp length 12, got %d", len(path)-1)
+	}
+
+	if path[0] != [2]int{7, 3} {
+		t.Errorf("path must start from hero point, got %v", path[0])
+	}
+
+	if path[len(path)-1] != [2]int{0, 4} {
+		t.Errorf("path must end at exit point, got %v", path[len(path)-1])
+	}
+
+	// each next point must be neighbour for previous one
+	for i := 1; i < len(path); i++ {
+		if abs(path[i][0]-path[i-1][0])+abs(path[i][1]-path[i-1][1]) != 1 {
+			t.Errorf("points %v and %v are not neighbours", path[i-1], path[i])
+		}
+
+		if labyrinthData[path[i][0]][path[i][1]] == WallPoint {
+			t.Errorf("path goes through wall %v", path[i])
+		}
+	}
+}
+
+func TestMinSurvivablePathAvoidLethalTraps(t *testing.T) {
+	var (
+		err, status error
+
+		labyrinthData [][]int
+		mspLength     int
+	)
+
+	labyrinthData, err = fetchLevelData(t, "../testdata/data_all_ok_2_msp_12.json")
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	// shortest route goes through both traps, so hero has to choose longer one
+	mspLength, status = MinSurvivablePathLen(labyrinthData, buildConstraints(2, 1, 1))
+	if status != nil {
+		t.Error(status.Error())
+		t.FailNow()
+	}
+
+	if mspLength != 16 {
+		t.Errorf("expected msp length 16, got %d", mspLength)
+	}
+}
+
+func TestMinSurvivablePathAllRoutesLethal(t *testing.T) {
+	var (
+		status error
+
+		labyrinthData [][]int
+	)
+
+	labyrinthData = [][]int{
+		{1, 1, 0, 1},
+		{1, 0, 2, 1},
+		{1, 4, 0, 1},
+		{1, 1, 1, 1},
+	}
+
+	_, status = MinSurvivablePath(labyrinthData, buildConstraints(1, 0, 0))
+	if status != nil {
+		t.Error(status.Error())
+	}
+
+	_, status = MinSurvivablePath(labyrinthData, buildConstraints(2, 2, 0))
+	if status != StatusNoSurvivablePath {
+		t.Errorf("expected no survivable path, got %v", status)
+	}
+}
+
+func TestAnalyzeExits(t *testing.T) {
+	var (
+		status error
+
+		labyrinthData [][]int
+		result        ResultType
+	)
+
+	// exits are gaps in left and bottom border
+	labyrinthData = [][]int{
+		{1, 1, 1, 1, 1},
+		{0, 0, 4, 0, 1},
+		{1, 1, 1, 0, 1},
+		{1, 1, 1, 0, 1},
+	}
+
+	result, status = Analyze(labyrinthData, buildConstraints(1, 0, 0))
+	if status != nil {
+		t.Error(status.Error())
+		t.FailNow()
+	}
+
+	if (result.Exit != [2]int{1, 0}) || (result.Length != 2) {
+		t.Errorf("expected nearest exit [1 0] with length 2, got %v with length %d", result.Exit, result.Length)
+	}
+
+	if (len(result.Exits) != 2) || (result.Exits[1].Point != [2]int{3, 3}) || (result.Exits[1].Length != 3) {
+		t.Errorf("expected second exit [3 3] with length 3, got %v", result.Exits)
+	}
+
+	if (result.Traps != 0) || (result.OpenTiles != 5) || (result.ReachableArea != 6) {
+		t.Errorf("expected 0 traps, 5 open tiles and reachable area 6, got %d, %d and %d", result.Traps, result.OpenTiles, result.ReachableArea)
+	}
+
+	// explicit exit marker hides gaps in border
+	labyrinthData[3][3] = ExitPoint
+
+	result, status = Analyze(labyrinthData, buildConstraints(1, 0, 0))
+	if status != nil {
+		t.Error(status.Error())
+		t.FailNow()
+	}
+
+	if (len(result.Exits) != 1) || (result.Exit != [2]int{3, 3}) {
+		t.Errorf("expected only exit [3 3], got %v", result.Exits)
+	}
+}
+
+func TestAnalyzeStatus(t *testing.T) {
+	var (
+		status error
+	)
+
+	levels := map[StatusType][][]int{
+		StatusNoHero:           {{1, 0, 1}, {1, 0, 1}, {1, 1, 1}},
+		StatusMultipleHeroes:   {{1, 0, 1}, {1, 4, 1}, {1, 4, 1}},
+		StatusNoExit:           {{1, 1, 1}, {1, 4, 1}, {1, 1, 1}},
+		StatusExitUnreachable:  {{1, 0, 1}, {1, 1, 1}, {1, 4, 1}},
+		StatusNoSurvivablePath: {{1, 0, 1}, {1, 2, 1}, {1, 4, 1}},
+	}
+
+	for expected, labyrinthData := range levels {
+		_, status = Analyze(labyrinthData, buildConstraints(1, 1, 1))
+		if status != expected {
+			t.Errorf("expected status %q, got %v", expected.Code(), status)
+		}
+	}
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
+
+func TestAnalyzeEmptyAndRagged(t *testing.T) {
+	var (
+		result ResultType
+		status error
+	)
+
+	_, status = Analyze(nil, buildConstraints(1, 1, 1))
+	if status != StatusNoHero {
+		t.Errorf("expected status %q for empty level, got %v", StatusNoHero.Code(), status)
+	}
+
+	_, status = Analyze([][]int{{}, {}}, buildConstraints(1, 1, 1))
+	if status != StatusNoHero {
+		t.Errorf("expected status %q for level with empty lines, got %v", StatusNoHero.Code(), status)
+	}
+
+	// lines of different length are linked only where neighbour exists
+	result, status = Analyze([][]int{{1, 0}, {1, 0, 1, 1}, {1, 4, 1}}, buildConstraints(1, 1, 1))
+	if (status != nil) || (result.Length != 2) {
+		t.Errorf("expected path of length 2 in ragged level, got %d: %v", result.Length, status)
+	}
+}
@@ -0,0 +1,108 @@
+package analyze
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	StatusSolved           StatusType = iota // hero can reach exit alive
+	StatusNoHero                             // level has no hero marker
+	StatusMultipleHeroes                     // level has more than one hero marker
+	StatusNoExit                             // level has no exit
+	StatusExitUnreachable                    // there is no route from hero to any exit
+	StatusNoSurvivablePath                   // every route from hero to exit is lethal
+)
+
+// status of level analysis, all statuses except StatusSolved are used as errors
+type StatusType int
+
+// structure describe result of level analysis
+type ResultType struct {
+	Status StatusType `json:"status"`
+	Length int        `json:"length"`
+	Path   [][2]int   `json:"path"`
+	Exit   [2]int     `json:"exit"`
+	Exits  []ExitType `json:"exits"`
+
+	Traps         int `json:"traps"`
+	OpenTiles     int `json:"open_tiles"`
+	ReachableArea int `json:"reachable_area"` // count of cells which hero can visit, including hero's cell
+}
+
+// structure describe distance from hero to single exit
+type ExitType struct {
+	Point  [2]int `json:"point"`
+	Length int    `json:"length"` // -1 if hero can't reach exit alive
+}
+
+// return short code of status which is suitable for api responses
+func (status StatusType) Code() string {
+	switch status {
+	case StatusSolved:
+		return "solved"
+	case StatusNoHero:
+		return "no_hero"
+	case StatusMultipleHeroes:
+		return "multiple_heroes"
+	case StatusNoExit:
+		return "no_exit"
+	case StatusExitUnreachable:
+		return "exit_unreachable"
+	case StatusNoSurvivablePath:
+		return "no_survivable_path"
+	}
+
+	return "unknown"
+}
+
+// find status by its short code.
+// return status and true if code is known
+func ParseStatus(code string) (status StatusType, ok bool) {
+	for status = StatusSolved; status <= StatusNoSurvivablePath; status++ {
+		if status.Code() == code {
+			return status, true
+		}
+	}
+
+	return StatusSolved, false
+}
+
+// encode status as its short code
+func (status StatusType) MarshalText() ([]byte, error) {
+	return []byte(status.Code()), nil
+}
+
+// decode status from its short code
+func (status *StatusType) UnmarshalText(text []byte) error {
+	var (
+		ok bool
+	)
+
+	*status, ok = ParseStatus(string(text))
+	if !ok {
+		return errors.New(fmt.Sprintf("unknown status %q", string(text)))
+	}
+
+	return nil
+}
+
+// return human readable description of status
+func (status StatusType) Error() string {
+	switch status {
+	case StatusSolved:
+		return "level is solved"
+	case StatusNoHero:
+		return "level has no hero"
+	case StatusMultipleHeroes:
+		return "level has more than one hero"
+	case StatusNoExit:
+		return "level has no exit"
+	case StatusExitUnreachable:
+		return "exits are unreachable from hero position"
+	case StatusNoSurvivablePath:
+		return "no survivable path"
+	}
+
+	return "unknown status"
+}
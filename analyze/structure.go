@@ -0,0 +1,114 @@
+package analyze
+
+import (
+	"sort"
+)
+
+// find every point of specific essence in labyrinth level data.
+// return [row, column] points ordered by rows and columns
+func FindPoints(labyrinthData [][]int, essence int) (points [][2]int) {
+	for y, line := range labyrinthData {
+		for x, value := range line {
+			if value == essence {
+				points = append(points, [2]int{y, x})
+			}
+		}
+	}
+
+	return points
+}
+
+// find exits of level: all cells with explicit exit marker, or open border cells if there is no marker.
+// return [row, column] points of exits ordered by rows and columns
+func FindExits(labyrinthData [][]int) (exits [][2]int) {
+	// explicit exit markers have priority over gaps in border
+	exits = FindPoints(labyrinthData, ExitPoint)
+	if len(exits) > 0 {
+		return exits
+	}
+
+	for y, line := range labyrinthData {
+		for x, value := range line {
+			// open cell in border is a gap, so hero can leave level through it
+			if (value == OpenTilePoint) && IsBorder(labyrinthData, y, x) {
+				exits = append(exits, [2]int{y, x})
+			}
+		}
+	}
+
+	return exits
+}
+
+// return true if point is in border of level: first or last line, first or last point of line
+func IsBorder(labyrinthData [][]int, y, x int) bool {
+	return (y == 0) || (y == len(labyrinthData)-1) || (x == 0) || (x == len(labyrinthData[y])-1)
+}
+
+// split cells which are not walls into areas, cells of area are linked by sides. lines may have different length.
+// return areas ordered by their first cell, cells of area are ordered by rows and columns
+func FindAreas(labyrinthData [][]int) (areas [][][2]int) {
+	var (
+		area    map[[2]int]int
+		visited int
+	)
+
+	// number of area for each visited cell
+	area = make(map[[2]int]int)
+
+	for y, line := range labyrinthData {
+		for x, value := range line {
+			var (
+				queue [][2]int
+				cells [][2]int
+			)
+
+			if (value == WallPoint) || (area[[2]int{y, x}] > 0) {
+				continue
+			}
+
+			// walk over new area by BFS from its first cell
+			visited++
+			area[[2]int{y, x}] = visited
+			queue = [][2]int{{y, x}}
+
+			for len(queue) > 0 {
+				var (
+					cell [2]int
+				)
+
+				cell, queue = queue[0], queue[1:]
+				cells = append(cells, cell)
+
+				for _, next := range [][2]int{{cell[0] - 1, cell[1]}, {cell[0] + 1, cell[1]}, {cell[0], cell[1] - 1}, {cell[0], cell[1] + 1}} {
+					if !isPassable(labyrinthData, next) || (area[next] > 0) {
+						continue
+					}
+
+					area[next] = visited
+					queue = append(queue, next)
+				}
+			}
+
+			sortPoints(cells)
+			areas = append(areas, cells)
+		}
+	}
+
+	return areas
+}
+
+// return true if cell exists in level and it's not wall
+func isPassable(labyrinthData [][]int, cell [2]int) bool {
+	if (cell[0] < 0) || (cell[0] >= len(labyrinthData)) || (cell[1] < 0) || (cell[1] >= len(labyrinthData[cell[0]])) {
+		return false
+	}
+
+	return labyrinthData[cell[0]][cell[1]] != WallPoint
+}
+
+// order points by rows and columns
+func sortPoints(points [][2]int) {
+	sort.Slice(points, func(i, j int) bool {
+		return (points[i][0] < points[j][0]) || ((points[i][0] == points[j][0]) && (points[i][1] < points[j][1]))
+	})
+}
@@ -0,0 +1,46 @@
+package analyze
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindExits(t *testing.T) {
+	var (
+		exits [][2]int
+	)
+
+	// open border cells are exits if there is no explicit marker
+	exits = FindExits([][]int{{1, 0, 1}, {0, 4, 1}, {1, 1, 1}})
+	if !reflect.DeepEqual(exits, [][2]int{{0, 1}, {1, 0}}) {
+		t.Errorf("unexpected exits: %v", exits)
+	}
+
+	exits = FindExits([][]int{{1, 0, 1}, {5, 4, 1}, {1, 1, 1}})
+	if !reflect.DeepEqual(exits, [][2]int{{1, 0}}) {
+		t.Errorf("unexpected explicit exits: %v", exits)
+	}
+}
+
+func TestFindAreas(t *testing.T) {
+	var (
+		areas [][][2]int
+	)
+
+	areas = FindAreas([][]int{
+		{0, 1, 0},
+		{0, 1, 2, 0},
+		{1, 1},
+		{3},
+	})
+
+	expected := [][][2]int{
+		{{0, 0}, {1, 0}},
+		{{0, 2}, {1, 2}, {1, 3}},
+		{{3, 0}},
+	}
+
+	if !reflect.DeepEqual(areas, expected) {
+		t.Errorf("expected areas %v, got %v", expected, areas)
+	}
+}
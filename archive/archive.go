@@ -0,0 +1,297 @@
+// package archive pack creator's game with all its levels into single zip file and read it back, so game can be
+// moved between environments
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"greenjade/analyze"
+	"greenjade/model"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+const (
+	MediaType    = "application/zip" // media type of game archive
+	Extension    = ".zip"            // extension of game archive file
+	Version      = 1                 // version of archive layout, archive of another version is rejected
+	ManifestName = "game.json"       // name of manifest file inside archive
+
+	UnpackRatio = 16 // max total size of unpacked files is this multiple of max request body
+
+	maxFileSize = 16 << 20 // max size of unpacked file inside archive, it protects memory from zip bomb
+	maxLevels   = 1000     // max count of levels in archive
+)
+
+// error of archive which can't be read: it's not zip, it has no manifest, or manifest doesn't match level files
+var ErrInvalidArchive = errors.New("invalid game archive")
+
+// manifest of archive, it describes game and lists its levels in order
+type manifestType struct {
+	Version  int
+	Exported time.Time
+	Creator  string
+	Game     string
+	Profile  string
+	Levels   []manifestLevelType
+}
+
+// level in manifest: number, name of level file and analysis results from source environment
+type manifestLevelType struct {
+	Level    int64
+	File     string
+	Analysis analyze.ResultType
+}
+
+// limits of archive which is read, they protect memory from small archive which unpacks into huge game
+type LimitsType struct {
+	Size int64 // max total size of unpacked files
+	Side int   // max count of lines and length of line of each level
+}
+
+// level file inside archive, it's the same as body of POST request, so single level can be stored by itself
+type levelFileType struct {
+	Creator  string
+	Game     string
+	Level    int64
+	Data     [][]int
+	Analysis analyze.ResultType
+}
+
+// collect creator's game and all its levels from storage.
+// return archive and nil, ErrNotFound or ErrStorage
+func Export(storage model.RepositoryType, creator, game string) (archive model.GameArchiveType, status error) {
+	var (
+		stored model.GameType
+		gameId int64
+	)
+
+	stored = model.GameType{Creator: creator, Game: game}
+
+	status = storage.LoadGame(&stored)
+	if status != nil {
+		return archive, status
+	}
+
+	archive = model.GameArchiveType{Creator: creator, Game: game, Profile: stored.Profile}
+
+	archive.Levels, gameId = storage.GetLevels(&stored)
+	switch {
+	case gameId < 0:
+		return archive, model.ErrStorage
+	case gameId == 0:
+		return archive, model.ErrNotFound
+	}
+
+	return archive, nil
+}
+
+// write archive as zip: manifest and single json file for each level, levels are in the same order as in archive.
+func Write(w io.Writer, archive model.GameArchiveType, exported time.Time) (err error) {
+	var (
+		writer   *zip.Writer
+		manifest manifestType
+	)
+
+	writer = zip.NewWriter(w)
+
+	manifest = manifestType{Version: Version, Exported: exported.UTC(), Creator: archive.Creator, Game: archive.Game,
+		Profile: archive.Profile, Levels: []manifestLevelType{}}
+
+	for _, level := range archive.Levels {
+		var (
+			file manifestLevelType
+		)
+
+		file = manifestLevelType{Level: level.Level, File: fmt.Sprintf("levels/%04d.json", level.Level), Analysis: level.Analysis}
+		manifest.Levels = append(manifest.Levels, file)
+
+		err = writeFile(writer, file.File, manifest.Exported, levelFileType{Creator: archive.Creator, Game: archive.Game,
+			Level: level.Level, Data: level.Data, Analysis: level.Analysis})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = writeFile(writer, ManifestName, manifest.Exported, manifest)
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+// add file with object as indented json to zip, file gets time of export
+func writeFile(writer *zip.Writer, name string, modified time.Time, obj interface{}) (err error) {
+	var (
+		file io.Writer
+		data []byte
+	)
+
+	data, err = json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	file, err = writer.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(append(data, '\n'))
+
+	return err
+}
+
+// read zip archive: manifest must have known version, creator, game, unique level numbers and unique level files,
+// each level file must exist. archive must fit limits: count of levels, total size of unpacked files and size of each
+// level. levels are not validated, it's done on import with constraints of target environment.
+// return archive or error which wraps ErrInvalidArchive
+func Read(input []byte, limits LimitsType) (archive model.GameArchiveType, err error) {
+	var (
+		reader   *zip.Reader
+		files    map[string]*zip.File
+		manifest manifestType
+		numbers  map[int64]bool
+		names    map[string]bool
+		budget   int64
+	)
+
+	reader, err = zip.NewReader(bytes.NewReader(input), int64(len(input)))
+	if err != nil {
+		return archive, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	files = make(map[string]*zip.File)
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+
+	budget = limits.Size
+
+	err = readFile(files, ManifestName, &manifest, &budget)
+	if err != nil {
+		return archive, err
+	}
+
+	if manifest.Version != Version {
+		return archive, fmt.Errorf("%w: version %d is not supported, expected %d", ErrInvalidArchive, manifest.Version, Version)
+	}
+
+	if (manifest.Creator == "") || (manifest.Game == "") {
+		return archive, fmt.Errorf("%w: creator and game are required", ErrInvalidArchive)
+	}
+
+	if len(manifest.Levels) > maxLevels {
+		return archive, fmt.Errorf("%w: archive has %d levels, expected at most %d", ErrInvalidArchive, len(manifest.Levels), maxLevels)
+	}
+
+	archive = model.GameArchiveType{Creator: manifest.Creator, Game: manifest.Game, Profile: manifest.Profile, Levels: []model.LevelType{}}
+	numbers = make(map[int64]bool)
+	names = map[string]bool{ManifestName: true}
+
+	for _, file := range manifest.Levels {
+		var (
+			level levelFileType
+		)
+
+		if numbers[file.Level] {
+			return archive, fmt.Errorf("%w: level %d is listed twice", ErrInvalidArchive, file.Level)
+		}
+
+		// each file is unpacked once, so total size limit can't be bypassed by repeated file
+		if names[file.File] {
+			return archive, fmt.Errorf("%w: file %s is listed twice", ErrInvalidArchive, file.File)
+		}
+
+		numbers[file.Level] = true
+		names[file.File] = true
+
+		err = readFile(files, file.File, &level, &budget)
+		if err != nil {
+			return archive, err
+		}
+
+		err = checkSide(level.Data, limits.Side)
+		if err != nil {
+			return archive, fmt.Errorf("%w: level %d %v", ErrInvalidArchive, file.Level, err)
+		}
+
+		// manifest is authoritative, level file may be edited by hand
+		archive.Levels = append(archive.Levels, model.LevelType{Creator: manifest.Creator, Game: manifest.Game,
+			Level: file.Level, Data: level.Data, Analysis: file.Analysis})
+	}
+
+	return archive, nil
+}
+
+// check count of lines and length of each line against max side.
+// return error if level is too big
+func checkSide(data [][]int, side int) error {
+	if len(data) > side {
+		return fmt.Errorf("has %d lines, expected at most %d", len(data), side)
+	}
+
+	for y, line := range data {
+		if len(line) > side {
+			return fmt.Errorf("line %d has %d points, expected at most %d", y+1, len(line), side)
+		}
+	}
+
+	return nil
+}
+
+// find file in archive and decode its json into object, size of unpacked file is taken from budget of archive.
+// return error which wraps ErrInvalidArchive
+func readFile(files map[string]*zip.File, name string, obj interface{}, budget *int64) (err error) {
+	var (
+		file   *zip.File
+		reader io.ReadCloser
+		data   []byte
+		limit  int64
+		ok     bool
+	)
+
+	file, ok = files[name]
+	if !ok {
+		return fmt.Errorf("%w: file %s is not found", ErrInvalidArchive, name)
+	}
+
+	reader, err = file.Open()
+	if err != nil {
+		return fmt.Errorf("%w: open %s: %v", ErrInvalidArchive, name, err)
+	}
+
+	defer func() {
+		if closeErr := reader.Close(); closeErr != nil {
+			fmt.Println("[error] close archive file:", closeErr)
+		}
+	}()
+
+	limit = maxFileSize
+	if *budget < limit {
+		limit = *budget
+	}
+
+	data, err = ioutil.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return fmt.Errorf("%w: read %s: %v", ErrInvalidArchive, name, err)
+	}
+
+	if int64(len(data)) > limit {
+		return fmt.Errorf("%w: file %s is bigger than %d bytes allowed for it", ErrInvalidArchive, name, limit)
+	}
+
+	*budget -= int64(len(data))
+
+	err = json.Unmarshal(data, obj)
+	if err != nil {
+		return fmt.Errorf("%w: decode %s: %v", ErrInvalidArchive, name, err)
+	}
+
+	return nil
+}
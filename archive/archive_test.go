@@ -0,0 +1,151 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"greenjade/config"
+	"greenjade/model"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// build zip with files of specific content
+func buildZip(t *testing.T, files map[string]string) []byte {
+	var (
+		err error
+
+		buffer bytes.Buffer
+		writer *zip.Writer
+	)
+
+	writer = zip.NewWriter(&buffer)
+
+	for name, content := range files {
+		var (
+			file io.Writer
+		)
+
+		file, err = writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = file.Write([]byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	var (
+		err, status error
+
+		storage *model.MemoryType
+		game    model.GameArchiveType
+		read    model.GameArchiveType
+		buffer  bytes.Buffer
+		profile string
+	)
+
+	storage = model.NewMemoryStorage()
+
+	// levels are stored out of order, archive keeps them ordered by number
+	for _, number := range []int64{3, 1, 2} {
+		level := model.LevelType{Creator: "designer", Game: "labyrinth", Level: number, Data: [][]int{{1, 4, 1}, {1, 0, 1}, {1, int(number % 2), 1}}}
+		if storage.StoreLevel(&level, config.ConstraintsType{}) < 1 {
+			t.Fatal("can't store level")
+		}
+	}
+
+	profile = "puzzle"
+
+	status = storage.UpdateGame(&model.GameType{Creator: "designer", Game: "labyrinth"}, "", &profile)
+	if status != nil {
+		t.Fatal(status)
+	}
+
+	game, status = Export(storage, "designer", "labyrinth")
+	if status != nil {
+		t.Fatal(status)
+	}
+
+	err = Write(&buffer, game, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read, err = Read(buffer.Bytes(), LimitsType{Size: 1 << 20, Side: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (read.Creator != "designer") || (read.Game != "labyrinth") || (read.Profile != "puzzle") || (len(read.Levels) != 3) {
+		t.Fatalf("unexpected archive: %+v", read)
+	}
+
+	for i, level := range read.Levels {
+		if (level.Level != int64(i+1)) || !reflect.DeepEqual(level.Data, game.Levels[i].Data) || !reflect.DeepEqual(level.Analysis, game.Levels[i].Analysis) {
+			t.Errorf("level %d: expected %+v, got %+v", i, game.Levels[i], level)
+		}
+	}
+
+	_, status = Export(storage, "designer", "unknown")
+	if status != model.ErrNotFound {
+		t.Errorf("expected not found for unknown game, got %v", status)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	var (
+		err error
+
+		many strings.Builder
+	)
+
+	level := `{"Data": [[1, 4, 1]]}`
+
+	many.WriteString(`{"Version": 1, "Creator": "designer", "Game": "labyrinth", "Levels": [`)
+	for i := 1; i <= maxLevels+1; i++ {
+		if i > 1 {
+			many.WriteString(", ")
+		}
+		fmt.Fprintf(&many, `{"Level": %d, "File": "%d.json"}`, i, i)
+	}
+	many.WriteString("]}")
+
+	for name, input := range map[string][]byte{
+		"not zip":       []byte("game"),
+		"no manifest":   buildZip(t, map[string]string{"levels/0001.json": level}),
+		"version":       buildZip(t, map[string]string{ManifestName: `{"Version": 2, "Creator": "designer", "Game": "labyrinth"}`}),
+		"no game":       buildZip(t, map[string]string{ManifestName: `{"Version": 1, "Creator": "designer"}`}),
+		"no level file": buildZip(t, map[string]string{ManifestName: `{"Version": 1, "Creator": "designer", "Game": "labyrinth", "Levels": [{"Level": 1, "File": "levels/0001.json"}]}`}),
+		"broken level":  buildZip(t, map[string]string{ManifestName: `{"Version": 1, "Creator": "designer", "Game": "labyrinth", "Levels": [{"Level": 1, "File": "1.json"}]}`, "1.json": "[["}),
+		"twice": buildZip(t, map[string]string{ManifestName: `{"Version": 1, "Creator": "designer", "Game": "labyrinth", "Levels": [{"Level": 1, "File": "1.json"}, {"Level": 1, "File": "1.json"}]}`,
+			"1.json": level}),
+		"same file": buildZip(t, map[string]string{ManifestName: `{"Version": 1, "Creator": "designer", "Game": "labyrinth", "Levels": [{"Level": 1, "File": "1.json"}, {"Level": 2, "File": "1.json"}]}`,
+			"1.json": level}),
+		"too many levels": buildZip(t, map[string]string{ManifestName: many.String(), "1.json": level}),
+		"too wide": buildZip(t, map[string]string{ManifestName: `{"Version": 1, "Creator": "designer", "Game": "labyrinth", "Levels": [{"Level": 1, "File": "1.json"}]}`,
+			"1.json": `{"Data": [[1, 4, 1, 1, 1]]}`}),
+		"too big": buildZip(t, map[string]string{ManifestName: `{"Version": 1, "Creator": "designer", "Game": "labyrinth", "Levels": [{"Level": 1, "File": "1.json"}]}`,
+			"1.json": `{"Data": [[1, 4, 1]]}` + strings.Repeat(" ", 1<<16)}),
+	} {
+		_, err = Read(input, LimitsType{Size: 1 << 16, Side: 4})
+		if !errors.Is(err, ErrInvalidArchive) {
+			t.Errorf("%s: expected invalid archive, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+// package bitmap import and export levels as pixel-art images, where each pixel is single point of level.
+// pixel color is converted into level point by mapping of colors.
+package bitmap
+
+import (
+	"bytes"
+	"fmt"
+	"greenjade/analyze"
+	"greenjade/codec"
+	"greenjade/config"
+	"image"
+	"image/color"
+	"image/png"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	MediaTypePNG = "image/png" // media type of pixel-art level
+)
+
+// mapping of pixel color as #rrggbb into level point, alpha channel is ignored
+type ColorsType map[string]int
+
+// default mapping, colors are the same as in level images of render package.
+// return mapping
+func DefaultColors() ColorsType {
+	return ColorsType{
+		"#f5f5f0": analyze.OpenTilePoint,
+		"#3c3c46": analyze.WallPoint,
+		"#1e140a": analyze.PitTrapPoint,
+		"#e68c14": analyze.ArrowTrapPoint,
+		"#1e64dc": analyze.HeroPoint,
+		"#28b450": analyze.ExitPoint,
+	}
+}
+
+// codec of pixel-art png image, each pixel is single point of level
+type PNGType struct {
+	Colors  ColorsType
+	MaxSide int // max width and height of image in pixels, zero means default max dimension
+}
+
+// register png codec with specific colors and max side of image, empty values mean default ones
+func Register(colors ColorsType, maxSide int) {
+	if len(colors) == 0 {
+		colors = DefaultColors()
+	}
+
+	codec.Register(PNGType{Colors: colors, MaxSide: maxSide})
+}
+
+func (PNGType) Name() string {
+	return "png"
+}
+
+func (PNGType) MediaType() string {
+	return MediaTypePNG
+}
+
+// return copy of codec with another max side of image
+func (obj PNGType) WithMaxSide(maxSide int) codec.CodecType {
+	obj.MaxSide = maxSide
+	return obj
+}
+
+// decode png image into level data, pixel in row y and column x is point data[y][x].
+// return level data or error with row and column (1-based) of pixel which color isn't mapped
+func (obj PNGType) Decode(input []byte) (data [][]int, err error) {
+	var (
+		header  image.Config
+		img     image.Image
+		bounds  image.Rectangle
+		points  map[color.NRGBA]int
+		maxSide int
+	)
+
+	// size is checked before decoding, so huge image doesn't take memory
+	header, err = png.DecodeConfig(bytes.NewReader(input))
+	if err != nil {
+		return nil, fmt.Errorf("invalid png image: %w", err)
+	}
+
+	maxSide = obj.MaxSide
+	if maxSide == 0 {
+		maxSide = config.DefaultDimensionMax
+	}
+
+	if (header.Width > maxSide) || (header.Height > maxSide) {
+		return nil, fmt.Errorf("image %dx%d is bigger than %dx%d pixels", header.Width, header.Height, maxSide, maxSide)
+	}
+
+	img, err = png.Decode(bytes.NewReader(input))
+	if err != nil {
+		return nil, fmt.Errorf("invalid png image: %w", err)
+	}
+
+	points, err = obj.Colors.points()
+	if err != nil {
+		return nil, err
+	}
+
+	bounds = img.Bounds()
+
+	data = make([][]int, bounds.Dy())
+	for y := range data {
+		data[y] = make([]int, bounds.Dx())
+
+		for x := range data[y] {
+			var (
+				pixel color.NRGBA
+				point int
+				ok    bool
+			)
+
+			pixel = color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+			pixel.A = 0xff
+
+			point, ok = points[pixel]
+			if !ok {
+				return nil, fmt.Errorf("unknown color %s in row %d, column %d", Hex(pixel), y+1, x+1)
+			}
+
+			data[y][x] = point
+		}
+	}
+
+	return data, nil
+}
+
+// encode level data as png image, lines are padded by open tiles up to the longest line.
+// each point gets the first color (in sorted order) which is mapped to it.
+// return png image
+func (obj PNGType) Encode(data [][]int) (output []byte, err error) {
+	var (
+		canvas *image.NRGBA
+		colors map[int]color.NRGBA
+		names  []string
+		width  int
+		buffer bytes.Buffer
+	)
+
+	// reverse mapping, colors are sorted to make result stable
+	for name := range obj.Colors {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	colors = make(map[int]color.NRGBA)
+	for _, name := range names {
+		var (
+			pixel color.NRGBA
+		)
+
+		pixel, err = ParseColor(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := colors[obj.Colors[name]]; !ok {
+			colors[obj.Colors[name]] = pixel
+		}
+	}
+
+	for _, line := range data {
+		if len(line) > width {
+			width = len(line)
+		}
+	}
+
+	canvas = image.NewNRGBA(image.Rect(0, 0, width, len(data)))
+
+	for y := range data {
+		for x := 0; x < width; x++ {
+			var (
+				point int
+				pixel color.NRGBA
+				ok    bool
+			)
+
+			point = analyze.OpenTilePoint
+			if x < len(data[y]) {
+				point = data[y][x]
+			}
+
+			pixel, ok = colors[point]
+			if !ok {
+				return nil, fmt.Errorf("point %d in row %d, column %d has no color in mapping", point, y+1, x+1)
+			}
+
+			canvas.SetNRGBA(x, y, pixel)
+		}
+	}
+
+	err = png.Encode(&buffer, canvas)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// convert mapping into colors.
+// return map of opaque colors into points, or error if some color can't be parsed
+func (obj ColorsType) points() (points map[color.NRGBA]int, err error) {
+	points = make(map[color.NRGBA]int)
+
+	for name, point := range obj {
+		var (
+			pixel color.NRGBA
+		)
+
+		pixel, err = ParseColor(name)
+		if err != nil {
+			return nil, err
+		}
+
+		points[pixel] = point
+	}
+
+	return points, nil
+}
+
+// parse color as #rrggbb, letters may be in any case.
+// return opaque color or error
+func ParseColor(name string) (pixel color.NRGBA, err error) {
+	var (
+		value uint64
+	)
+
+	if (len(name) != 7) || !strings.HasPrefix(name, "#") {
+		return pixel, fmt.Errorf("color %q must be #rrggbb", name)
+	}
+
+	value, err = strconv.ParseUint(name[1:], 16, 32)
+	if err != nil {
+		return pixel, fmt.Errorf("color %q must be #rrggbb", name)
+	}
+
+	return color.NRGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 0xff}, nil
+}
+
+// return color as #rrggbb
+func Hex(pixel color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", pixel.R, pixel.G, pixel.B)
+}
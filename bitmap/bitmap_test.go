@@ -0,0 +1,121 @@
+package bitmap
+
+import (
+	"bytes"
+	"greenjade/codec"
+	"greenjade/config"
+	"image"
+	"image/color"
+	"image/png"
+	"reflect"
+	"testing"
+)
+
+func TestPNGRoundTrip(t *testing.T) {
+	var (
+		err error
+
+		output []byte
+		data   [][]int
+	)
+
+	level := [][]int{
+		{1, 1, 5, 1},
+		{1, 2, 3, 1},
+		{1, 4, 0, 1},
+		{1, 1, 1, 1},
+	}
+
+	output, err = PNGType{Colors: DefaultColors()}.Encode(level)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err = PNGType{Colors: DefaultColors()}.Decode(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(data, level) {
+		t.Errorf("expected %v, got %v", level, data)
+	}
+}
+
+func TestPNGDecode(t *testing.T) {
+	var (
+		err error
+
+		canvas *image.Paletted
+		buffer bytes.Buffer
+		data   [][]int
+	)
+
+	// paletted image with semi-transparent pixel, alpha is ignored
+	canvas = image.NewPaletted(image.Rect(0, 0, 3, 2), color.Palette{
+		color.NRGBA{A: 0xff},
+		color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		color.NRGBA{R: 0xff, A: 0x80},
+	})
+	canvas.SetColorIndex(1, 0, 1)
+	canvas.SetColorIndex(2, 1, 2)
+
+	err = png.Encode(&buffer, canvas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err = PNGType{Colors: ColorsType{"#000000": 1, "#FFFFFF": 0, "#ff0000": 2}}.Decode(buffer.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(data, [][]int{{1, 0, 1}, {1, 1, 2}}) {
+		t.Errorf("unexpected level data %v", data)
+	}
+
+	_, err = PNGType{Colors: ColorsType{"#000000": 1, "#ffffff": 0}}.Decode(buffer.Bytes())
+	if (err == nil) || (err.Error() != "unknown color #ff0000 in row 2, column 3") {
+		t.Errorf("expected error for unknown color, got %v", err)
+	}
+
+	_, err = PNGType{Colors: DefaultColors()}.Decode([]byte("GIF89a"))
+	if err == nil {
+		t.Error("expected error for image which isn't png")
+	}
+
+	_, err = PNGType{Colors: ColorsType{"white": 0}}.Decode(buffer.Bytes())
+	if err == nil {
+		t.Error("expected error for invalid color in mapping")
+	}
+}
+
+func TestPNGTooBig(t *testing.T) {
+	var (
+		err error
+
+		buffer bytes.Buffer
+	)
+
+	err = png.Encode(&buffer, image.NewGray(image.Rect(0, 0, config.DefaultDimensionMax+1, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = PNGType{Colors: DefaultColors()}.Decode(buffer.Bytes())
+	if err == nil {
+		t.Error("expected error for too big image")
+	}
+
+	// max side of actual constraints replaces one of registration
+	buffer.Reset()
+
+	err = png.Encode(&buffer, image.NewGray(image.Rect(0, 0, 3, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = codec.WithMaxSide(PNGType{Colors: DefaultColors(), MaxSide: 10}, 2).Decode(buffer.Bytes())
+	if (err == nil) || (err.Error() != "image 3x1 is bigger than 2x2 pixels") {
+		t.Errorf("expected error for image bigger than actual max side, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package codec
+
+import (
+	"fmt"
+	"greenjade/analyze"
+	"strings"
+)
+
+const (
+	MediaTypeASCII = "text/plain" // media type of character map format
+)
+
+// characters of map for each labyrinth level essence
+var asciiChars = map[int]byte{
+	analyze.OpenTilePoint:  '.',
+	analyze.WallPoint:      '#',
+	analyze.PitTrapPoint:   'O',
+	analyze.ArrowTrapPoint: '>',
+	analyze.HeroPoint:      '@',
+	analyze.ExitPoint:      'E',
+}
+
+// character map format: each line of text is line of level, each character is point
+type ASCIIType struct{}
+
+func init() {
+	Register(ASCIIType{})
+}
+
+func (ASCIIType) Name() string {
+	return "ascii"
+}
+
+func (ASCIIType) MediaType() string {
+	return MediaTypeASCII
+}
+
+// convert character map into level data. windows line endings and empty lines at the end are ignored.
+// return level data or error with line and column (1-based) of unknown character
+func (ASCIIType) Decode(input []byte) (data [][]int, err error) {
+	var (
+		lines  []string
+		points map[byte]int
+	)
+
+	points = make(map[byte]int)
+	for point, char := range asciiChars {
+		points[char] = point
+	}
+
+	lines = strings.Split(strings.ReplaceAll(string(input), "\r\n", "\n"), "\n")
+
+	// editors usually add new line at the end of file
+	for (len(lines) > 0) && (lines[len(lines)-1] == "") {
+		lines = lines[:len(lines)-1]
+	}
+
+	data = make([][]int, len(lines))
+	for y, line := range lines {
+		data[y] = make([]int, len(line))
+
+		for x := 0; x < len(line); x++ {
+			var (
+				point int
+				ok    bool
+			)
+
+			point, ok = points[line[x]]
+			if !ok {
+				return nil, fmt.Errorf("unknown character %q in line %d, column %d", line[x], y+1, x+1)
+			}
+
+			data[y][x] = point
+		}
+	}
+
+	return data, nil
+}
+
+// convert level data into character map, each line ends with new line.
+// return text or error with line and column (1-based) of point which has no character
+func (ASCIIType) Encode(data [][]int) (output []byte, err error) {
+	var (
+		builder strings.Builder
+	)
+
+	for y, line := range data {
+		for x, point := range line {
+			var (
+				char byte
+				ok   bool
+			)
+
+			char, ok = asciiChars[point]
+			if !ok {
+				return nil, fmt.Errorf("point %d in line %d, column %d has no character", point, y+1, x+1)
+			}
+
+			builder.WriteByte(char)
+		}
+
+		builder.WriteByte('\n')
+	}
+
+	return []byte(builder.String()), nil
+}
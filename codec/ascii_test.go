@@ -0,0 +1,61 @@
+package codec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestASCIIRoundTrip(t *testing.T) {
+	var (
+		err error
+
+		codec  CodecType
+		data   [][]int
+		output []byte
+	)
+
+	codec, err = ByMediaType("text/plain; charset=utf-8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err = codec.Decode([]byte("##E#\r\n#.O#\n#@>#\n####\n\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]int{{1, 1, 5, 1}, {1, 0, 2, 1}, {1, 4, 3, 1}, {1, 1, 1, 1}}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("expected %v, got %v", expected, data)
+	}
+
+	output, err = codec.Encode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(output) != "##E#\n#.O#\n#@>#\n####\n" {
+		t.Errorf("unexpected map:\n%s", output)
+	}
+}
+
+func TestASCIIErrors(t *testing.T) {
+	var (
+		err error
+	)
+
+	_, err = ASCIIType{}.Decode([]byte("###\n#x#\n"))
+	if (err == nil) || (err.Error() != `unknown character 'x' in line 2, column 2`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	_, err = ASCIIType{}.Encode([][]int{{1, 9}})
+	if err == nil {
+		t.Error("expected error for point without character")
+	}
+
+	_, err = ByName("xml")
+	if err != ErrUnknownFormat {
+		t.Errorf("expected unknown format, got %v", err)
+	}
+}
@@ -0,0 +1,474 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"greenjade/config"
+)
+
+const (
+	MediaTypeBinary = "application/octet-stream" // media type of compact binary format
+
+	binaryMagic   = "GJL" // first bytes of binary level, they distinguish it from json data
+	binaryVersion = 1     // version of binary layout, it's written after magic
+
+	binaryRagged = 1 << 0 // header flag: lines have different length, each length is written
+	binaryPacked = 1 << 1 // header flag: body is bit-packed points instead of runs
+
+	maxBinaryCells = 1 << 24 // max count of points in decoded level, it protects memory from broken header
+)
+
+/*
+compact binary format of level data. header is magic "GJL", version byte, flags byte, height, then width (or length
+of each line if flag ragged is set). body is runs of equal points in row-major order, each run is count and point,
+or (if flag packed is set) the smallest point, bits per point and points minus the smallest one packed into bits.
+encoder chooses the shorter body: runs suit levels with long walls and corridors, bits suit noisy ones.
+numbers are varints of encoding/binary (counts and sizes unsigned, points signed), so level takes from few bytes per
+line to half of byte per point instead of two bytes per point of json.
+max side limits count of lines and length of line before anything is allocated, zero means no limit except count of
+points, it's used for data read from storage which was checked on save
+*/
+type BinaryType struct {
+	MaxSide int
+}
+
+func init() {
+	Register(BinaryType{MaxSide: config.DefaultDimensionMax})
+}
+
+func (BinaryType) Name() string {
+	return "binary"
+}
+
+func (BinaryType) MediaType() string {
+	return MediaTypeBinary
+}
+
+// return copy of codec with another max side
+func (obj BinaryType) WithMaxSide(maxSide int) CodecType {
+	obj.MaxSide = maxSide
+	return obj
+}
+
+// return true if data starts with header of binary format
+func IsBinary(input []byte) bool {
+	return bytes.HasPrefix(input, []byte(binaryMagic))
+}
+
+// decode binary level, every point of header's size must be covered by runs and nothing must be left after them.
+// header is checked against max side and against size of input before level is allocated.
+// return level data or error if input is broken
+func (obj BinaryType) Decode(input []byte) (data [][]int, err error) {
+	var (
+		reader        *bytes.Reader
+		version, flag byte
+		height, width uint64
+		widths        []uint64
+		cells         uint64
+	)
+
+	if !IsBinary(input) {
+		return nil, errors.New("binary level must start with " + binaryMagic)
+	}
+
+	reader = bytes.NewReader(input[len(binaryMagic):])
+
+	version, err = reader.ReadByte()
+	if err != nil {
+		return nil, errors.New("binary level has no version")
+	}
+
+	if version != binaryVersion {
+		return nil, fmt.Errorf("binary level version %d is not supported, expected %d", version, binaryVersion)
+	}
+
+	flag, err = reader.ReadByte()
+	if err != nil {
+		return nil, errors.New("binary level has no flags")
+	}
+
+	height, err = binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, errors.New("binary level has no height")
+	}
+
+	err = obj.checkSide(height, "height")
+	if err != nil {
+		return nil, err
+	}
+
+	// each length of ragged line takes at least one byte, so count of lines can't exceed input
+	if (flag&binaryRagged != 0) && (height > uint64(reader.Len())) {
+		return nil, fmt.Errorf("binary level has %d lines, but only %d bytes", height, reader.Len())
+	}
+
+	// rectangular level keeps single width for all lines, lines of zero width are written as ragged ones
+	if flag&binaryRagged == 0 {
+		width, err = binary.ReadUvarint(reader)
+		if err != nil {
+			return nil, errors.New("binary level has no width")
+		}
+
+		if (height > 0) && (width == 0) {
+			return nil, fmt.Errorf("binary level has %d lines of zero width", height)
+		}
+	}
+
+	widths = make([]uint64, height)
+	for y := range widths {
+		widths[y] = width
+
+		if flag&binaryRagged != 0 {
+			widths[y], err = binary.ReadUvarint(reader)
+			if err != nil {
+				return nil, fmt.Errorf("binary level has no length of line %d", y+1)
+			}
+		}
+
+		err = obj.checkSide(widths[y], "width")
+		if err != nil {
+			return nil, err
+		}
+
+		cells += widths[y]
+		if cells > maxBinaryCells {
+			return nil, fmt.Errorf("binary level is bigger than %d points", maxBinaryCells)
+		}
+	}
+
+	err = checkBody(reader, flag, cells)
+	if err != nil {
+		return nil, err
+	}
+
+	data = make([][]int, height)
+	for y := range data {
+		data[y] = make([]int, widths[y])
+	}
+
+	if flag&binaryPacked != 0 {
+		err = readPacked(reader, data, cells)
+	} else {
+		err = readRuns(reader, data)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if reader.Len() > 0 {
+		return nil, fmt.Errorf("binary level has %d unexpected bytes after data", reader.Len())
+	}
+
+	return data, nil
+}
+
+// check that count of lines or length of line fits max side.
+// return error if it's too big
+func (obj BinaryType) checkSide(side uint64, name string) error {
+	if side > maxBinaryCells {
+		return fmt.Errorf("binary level %s %d is too big", name, side)
+	}
+
+	if (obj.MaxSide > 0) && (side > uint64(obj.MaxSide)) {
+		return fmt.Errorf("binary level %s %d is bigger than %d", name, side, obj.MaxSide)
+	}
+
+	return nil
+}
+
+// check that rest of input can cover declared points: runs take at least two bytes, packed bits take exactly
+// count of points by bits per point, reader is left at start of body.
+// return error if input is too short for declared size
+func checkBody(reader *bytes.Reader, flag byte, cells uint64) error {
+	var (
+		err error
+
+		bits byte
+		size uint64
+	)
+
+	if cells == 0 {
+		return nil
+	}
+
+	if flag&binaryPacked == 0 {
+		if reader.Len() < 2 {
+			return fmt.Errorf("binary level has %d points, but no runs", cells)
+		}
+
+		return nil
+	}
+
+	start := reader.Size() - int64(reader.Len())
+	defer reader.Seek(start, io.SeekStart)
+
+	_, err = binary.ReadVarint(reader)
+	if err != nil {
+		return errors.New("binary level has no smallest point")
+	}
+
+	bits, err = reader.ReadByte()
+	if (err != nil) || (bits > 64) {
+		return errors.New("binary level has no valid count of bits per point")
+	}
+
+	size = (cells*uint64(bits) + 7) / 8
+	if uint64(reader.Len()) != size {
+		return fmt.Errorf("binary level data has %d bytes, expected %d", reader.Len(), size)
+	}
+
+	return nil
+}
+
+// fill level data by runs of equal points in row-major order.
+// return error if runs don't cover level exactly
+func readRuns(reader *bytes.Reader, data [][]int) (err error) {
+	var (
+		y, x  int
+		count uint64
+		point int64
+	)
+
+	for {
+		// skip empty lines, they have no points to fill
+		for (y < len(data)) && (x == len(data[y])) {
+			y, x = y+1, 0
+		}
+
+		if y == len(data) {
+			return nil
+		}
+
+		count, err = binary.ReadUvarint(reader)
+		if err != nil {
+			return fmt.Errorf("binary level data ends in line %d, column %d", y+1, x+1)
+		}
+
+		point, err = binary.ReadVarint(reader)
+		if err != nil {
+			return fmt.Errorf("binary level data ends in line %d, column %d", y+1, x+1)
+		}
+
+		if count == 0 {
+			return fmt.Errorf("binary level has empty run in line %d, column %d", y+1, x+1)
+		}
+
+		for ; count > 0; count-- {
+			for (y < len(data)) && (x == len(data[y])) {
+				y, x = y+1, 0
+			}
+
+			if y == len(data) {
+				return errors.New("binary level run is longer than level")
+			}
+
+			data[y][x] = int(point)
+			x++
+		}
+	}
+}
+
+// fill level data by bit-packed points in row-major order, lowest bits of byte come first.
+// return error if packed bits don't cover level exactly
+func readPacked(reader *bytes.Reader, data [][]int, cells uint64) (err error) {
+	var (
+		min         int64
+		bits        byte
+		packed      []byte
+		offset      uint64
+		size, index uint64
+	)
+
+	min, err = binary.ReadVarint(reader)
+	if err != nil {
+		return errors.New("binary level has no smallest point")
+	}
+
+	bits, err = reader.ReadByte()
+	if (err != nil) || (bits > 64) {
+		return errors.New("binary level has no valid count of bits per point")
+	}
+
+	size = (cells*uint64(bits) + 7) / 8
+	if uint64(reader.Len()) < size {
+		return fmt.Errorf("binary level data has %d bytes, expected %d", reader.Len(), size)
+	}
+
+	packed = make([]byte, size)
+	_, _ = reader.Read(packed)
+
+	for y := range data {
+		for x := range data[y] {
+			var (
+				value uint64
+			)
+
+			for bit := uint64(0); bit < uint64(bits); bit++ {
+				index = offset + bit
+				value |= uint64(packed[index/8]>>(index%8)&1) << bit
+			}
+
+			offset += uint64(bits)
+			data[y][x] = int(min + int64(value))
+		}
+	}
+
+	return nil
+}
+
+// encode level data as runs and as packed bits, the shorter one is used.
+// return binary level
+func (BinaryType) Encode(data [][]int) (output []byte, err error) {
+	var (
+		buffer       bytes.Buffer
+		runs, packed []byte
+		flag         byte
+	)
+
+	for _, line := range data {
+		if (len(line) != len(data[0])) || (len(line) == 0) {
+			flag |= binaryRagged
+		}
+	}
+
+	runs = encodeRuns(data)
+	packed = encodePacked(data)
+
+	if len(packed) < len(runs) {
+		flag |= binaryPacked
+	}
+
+	buffer.WriteString(binaryMagic)
+	buffer.WriteByte(binaryVersion)
+	buffer.WriteByte(flag)
+	writeUvarint(&buffer, uint64(len(data)))
+
+	switch {
+	case flag&binaryRagged != 0:
+		for _, line := range data {
+			writeUvarint(&buffer, uint64(len(line)))
+		}
+	case len(data) > 0:
+		writeUvarint(&buffer, uint64(len(data[0])))
+	default:
+		writeUvarint(&buffer, 0)
+	}
+
+	if flag&binaryPacked != 0 {
+		buffer.Write(packed)
+	} else {
+		buffer.Write(runs)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// encode points as runs, runs continue across lines, so walls of line's end and next line's start are single run.
+// return body of binary level
+func encodeRuns(data [][]int) []byte {
+	var (
+		buffer  bytes.Buffer
+		count   uint64
+		current int
+	)
+
+	for _, line := range data {
+		for _, point := range line {
+			if (count > 0) && (point != current) {
+				writeUvarint(&buffer, count)
+				writeVarint(&buffer, int64(current))
+				count = 0
+			}
+
+			current = point
+			count++
+		}
+	}
+
+	if count > 0 {
+		writeUvarint(&buffer, count)
+		writeVarint(&buffer, int64(current))
+	}
+
+	return buffer.Bytes()
+}
+
+// encode points as the smallest point, bits per point and packed points minus the smallest one.
+// return body of binary level
+func encodePacked(data [][]int) []byte {
+	var (
+		buffer   bytes.Buffer
+		min, max int64
+		bits     byte
+		packed   []byte
+		offset   uint64
+		found    bool
+	)
+
+	for _, line := range data {
+		for _, point := range line {
+			if !found || (int64(point) < min) {
+				min = int64(point)
+			}
+
+			if !found || (int64(point) > max) {
+				max = int64(point)
+			}
+
+			found = true
+		}
+	}
+
+	for (bits < 64) && (uint64(max-min)>>bits != 0) {
+		bits++
+	}
+
+	writeVarint(&buffer, min)
+	buffer.WriteByte(bits)
+
+	for _, line := range data {
+		for _, point := range line {
+			var (
+				value uint64
+			)
+
+			value = uint64(int64(point) - min)
+
+			for bit := uint64(0); bit < uint64(bits); bit++ {
+				if offset/8 >= uint64(len(packed)) {
+					packed = append(packed, 0)
+				}
+
+				packed[offset/8] |= byte(value>>bit&1) << (offset % 8)
+				offset++
+			}
+		}
+	}
+
+	buffer.Write(packed)
+
+	return buffer.Bytes()
+}
+
+// write unsigned varint into buffer
+func writeUvarint(buffer *bytes.Buffer, value uint64) {
+	var (
+		scratch [binary.MaxVarintLen64]byte
+	)
+
+	buffer.Write(scratch[:binary.PutUvarint(scratch[:], value)])
+}
+
+// write signed varint into buffer
+func writeVarint(buffer *bytes.Buffer, value int64) {
+	var (
+		scratch [binary.MaxVarintLen64]byte
+	)
+
+	buffer.Write(scratch[:binary.PutVarint(scratch[:], value)])
+}
@@ -0,0 +1,177 @@
+package codec
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBinaryRoundTripJSON(t *testing.T) {
+	var (
+		err error
+
+		names []string
+	)
+
+	names, err = filepath.Glob("../testdata/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range names {
+		var (
+			input, output []byte
+			level         struct{ Data [][]int }
+			data          [][]int
+		)
+
+		input, err = ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = json.Unmarshal(input, &level)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+
+		output, err = BinaryType{}.Encode(level.Data)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+
+		data, err = BinaryType{}.Decode(output)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(data, level.Data) {
+			t.Errorf("%s: expected %v, got %v", name, level.Data, data)
+		}
+
+		input, _ = json.Marshal(level.Data)
+		if len(output) >= len(input) {
+			t.Errorf("%s: binary level takes %d bytes, json one takes %d", name, len(output), len(input))
+		}
+	}
+}
+
+func TestBinaryRoundTripShapes(t *testing.T) {
+	var (
+		err error
+
+		output []byte
+		data   [][]int
+	)
+
+	big := make([][]int, 100)
+	for y := range big {
+		big[y] = make([]int, 100)
+		for x := range big[y] {
+			if (y == 0) || (x == 0) || (y == 99) || (x == 99) || (x%2 == 0 && y%10 != 5) {
+				big[y][x] = 1
+			}
+		}
+	}
+
+	for i, level := range [][][]int{
+		{},
+		{{}},
+		{{1, 1}, {}, {1}, {-7, 300, 1 << 40}},
+		{{0, 0, 0}, {0, 0, 0}},
+		big,
+	} {
+		output, err = BinaryType{}.Encode(level)
+		if err != nil {
+			t.Errorf("level %d: %v", i, err)
+			continue
+		}
+
+		data, err = BinaryType{}.Decode(output)
+		if err != nil {
+			t.Errorf("level %d: %v", i, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(data, level) {
+			t.Errorf("level %d: expected %v, got %v", i, level, data)
+		}
+	}
+
+	// noisy level 100x100 of walls and open tiles takes single bit per point
+	output, _ = BinaryType{}.Encode(big)
+	if (len(output) > 1300) || (output[4]&binaryPacked == 0) {
+		t.Errorf("expected bit-packed level, got %d bytes", len(output))
+	}
+}
+
+func TestBinaryErrors(t *testing.T) {
+	var (
+		err error
+
+		codec CodecType
+	)
+
+	codec, err = ByMediaType(MediaTypeBinary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, input := range map[string]string{
+		"json":          "[[1,1],[1,1]]",
+		"version":       "GJL\x02\x00\x01\x01\x01\x02",
+		"no header":     "GJL\x01",
+		"short data":    "GJL\x01\x00\x02\x02\x03\x02",
+		"long run":      "GJL\x01\x00\x01\x02\x03\x02",
+		"empty run":     "GJL\x01\x00\x01\x02\x00\x02\x02\x02",
+		"extra bytes":   "GJL\x01\x00\x01\x02\x02\x02\x00",
+		"huge size":     "GJL\x01\x00\xff\xff\xff\xff\x0f\xff\xff\xff\xff\x0f",
+		"ragged lines":  "GJL\x01\x01\x02\x01",
+		"short bits":    "GJL\x01\x02\x01\x09\x00\x01\x00",
+		"too many bits": "GJL\x01\x02\x01\x01\x00\x41\x00",
+		"zero width":    "GJL\x01\x00\x80\x80\x80\x08\x00",
+		"wide line":     "GJL\x01\x00\x01\x80\x80\x04\x02\x02",
+		"few lines":     "GJL\x01\x01\x80\x80\x04\x01",
+		"long bits":     "GJL\x01\x02\x01\x01\x00\x01\x00\x00",
+	} {
+		_, err = codec.Decode([]byte(input))
+		if err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestBinaryMaxSide(t *testing.T) {
+	var (
+		err error
+
+		output []byte
+	)
+
+	data := [][]int{{1, 1, 1}, {1, 4, 5}, {1, 1, 1}}
+
+	output, err = BinaryType{}.Encode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// codec keeps max side of registration, copy gets actual one
+	_, err = WithMaxSide(BinaryType{MaxSide: 3}, 2).Decode(output)
+	if err == nil {
+		t.Error("expected error for level bigger than max side")
+	}
+
+	_, err = WithMaxSide(BinaryType{MaxSide: 2}, 3).Decode(output)
+	if err != nil {
+		t.Errorf("expected level which fits max side, got %v", err)
+	}
+
+	// codec without max side is returned as it is
+	if WithMaxSide(ASCIIType{}, 2) != (ASCIIType{}) {
+		t.Error("expected the same ascii codec")
+	}
+}
@@ -0,0 +1,112 @@
+// package codec convert level data to and from different formats
+package codec
+
+import (
+	"errors"
+	"mime"
+	"sort"
+)
+
+// error of unknown format or media type
+var ErrUnknownFormat = errors.New("unknown level format")
+
+// converter of level data ([][]int of LevelType.Data) to and from specific format
+type CodecType interface {
+	Name() string      // short name of format for query parameters, e.g. "ascii"
+	MediaType() string // media type for Content-Type headers, e.g. "text/plain"
+	Decode(input []byte) (data [][]int, err error)
+	Encode(data [][]int) (output []byte, err error)
+}
+
+// level's creator, game and number which some formats keep together with level data
+type MetaType struct {
+	Creator string
+	Game    string
+	Level   int64
+}
+
+// codec of format which keeps level's creator, game and number inside, e.g. as map properties
+type MetaCodecType interface {
+	CodecType
+	DecodeLevel(input []byte) (meta MetaType, data [][]int, err error)
+	EncodeLevel(meta MetaType, data [][]int) (output []byte, err error)
+}
+
+// codec which limits count of lines and length of line of decoded level, so huge input is rejected before level is
+// allocated. max side comes from constraints which may be reloaded, so it's set for each decoding
+type SizedCodecType interface {
+	CodecType
+	WithMaxSide(maxSide int) CodecType
+}
+
+// all known codecs by name
+var codecs = map[string]CodecType{}
+
+// add codec to list of known ones, codec with the same name is replaced. codecs which don't need settings are
+// registered by init of their files, other ones are registered on start.
+func Register(codec CodecType) {
+	codecs[codec.Name()] = codec
+}
+
+// find codec by short name of format.
+// return codec or ErrUnknownFormat
+func ByName(name string) (codec CodecType, err error) {
+	var (
+		ok bool
+	)
+
+	codec, ok = codecs[name]
+	if !ok {
+		return nil, ErrUnknownFormat
+	}
+
+	return codec, nil
+}
+
+// find codec by value of Content-Type header, parameters like charset are ignored.
+// return codec or ErrUnknownFormat
+func ByMediaType(contentType string) (codec CodecType, err error) {
+	var (
+		mediaType string
+	)
+
+	mediaType, _, err = mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, ErrUnknownFormat
+	}
+
+	for _, codec = range codecs {
+		if codec.MediaType() == mediaType {
+			return codec, nil
+		}
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+// set max side of level for codec which supports it, other codecs don't allocate more than their input.
+// return codec to decode level with
+func WithMaxSide(codec CodecType, maxSide int) CodecType {
+	var (
+		sized SizedCodecType
+		ok    bool
+	)
+
+	sized, ok = codec.(SizedCodecType)
+	if !ok {
+		return codec
+	}
+
+	return sized.WithMaxSide(maxSide)
+}
+
+// return sorted names of all known formats
+func Names() (names []string) {
+	for name := range codecs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
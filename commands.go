@@ -0,0 +1,577 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"greenjade/analyze"
+	"greenjade/archive"
+	"greenjade/codec"
+	"greenjade/config"
+	"greenjade/model"
+	"greenjade/render"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// command which is run instead of service, it gets arguments after its name.
+// return exit code
+type commandType func(args []string) int
+
+// commands of service binary: go run . <command> [flags]
+var commands = map[string]commandType{
+	"render":  runRender,
+	"convert": runConvert,
+	"import":  runImport,
+
+	"export-game": runExportGame,
+	"import-game": runImportGame,
+}
+
+const (
+	FormatJSON = "json" // format of level json, the same as POST body
+)
+
+/*
+draw level from file as png or svg image:
+go run . render -in testdata/data_all_ok_1_1.json -out level.png [-cell 16] [-path=false] [-config config.yml]
+input is level json (the same as POST body) or file of any codec format by extension (.txt is ascii, .tmx and
+.tmj are Tiled maps which are read with tile mapping of config file, .png is pixel-art image where each pixel
+is single point and colors are mapped by config file).
+path is calculated with constraints of config file, defaults are used without it.
+return exit code
+*/
+func runRender(args []string) int {
+	var (
+		err error
+
+		fs               *flag.FlagSet
+		in, out, cfgFile *string
+		format           *string
+		cell             *int
+		drawPath         *bool
+		cfg              *config.ConfType
+		level            model.LevelType
+		data             [][]int
+		result           analyze.ResultType
+		output           []byte
+	)
+
+	fs = flag.NewFlagSet("render", flag.ContinueOnError)
+	in = fs.String("in", "", "level file: json level or codec format by extension (.txt is ascii, .tmx/.tmj is Tiled map, .png is pixel-art image)")
+	out = fs.String("out", "", "image file, format is chosen by extension if -format isn't set")
+	format = fs.String("format", "", "image format: png or svg")
+	cell = fs.Int("cell", render.DefaultCellSize, "size of single point in pixels")
+	drawPath = fs.Bool("path", true, "draw minimal survivable path")
+	cfgFile = fs.String("config", "", "config file with constraints for path calculation")
+
+	err = fs.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	if (*in == "") || (*out == "") {
+		fmt.Println("[error] -in and -out are required")
+		fs.Usage()
+		return 2
+	}
+
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(*out), ".")
+	}
+
+	// only constraints are needed, so storage settings are not validated
+	cfg = &config.ConfType{}
+	if *cfgFile != "" {
+		cfg, err = config.ReadConfig(*cfgFile)
+		if err != nil {
+			fmt.Println("[error] read config:", err)
+			return 1
+		}
+	}
+
+	cfg.SetDefaults()
+	registerCodecs(cfg)
+
+	level, err = readLevelFile(*in)
+	if err != nil {
+		fmt.Println("[error] read level:", err)
+		return 1
+	}
+
+	data = level.Data
+
+	if *drawPath {
+		result, err = analyze.Analyze(data, cfg.Constraints)
+		if err != nil {
+			fmt.Println("level has no path:", err)
+		}
+	}
+
+	output, _, err = render.Render(*format, data, render.OptionsType{CellSize: *cell, Path: result.Path})
+	if err != nil {
+		fmt.Println("[error] render level:", err)
+		return 1
+	}
+
+	err = ioutil.WriteFile(*out, output, 0644)
+	if err != nil {
+		fmt.Println("[error] write image:", err)
+		return 1
+	}
+
+	fmt.Println("image is written to", *out)
+
+	return 0
+}
+
+/*
+convert level file from one format to another, formats are chosen by extensions the same as for render input:
+go run . convert -in level.tmx -out level.json [-config config.yml]
+e.g. Tiled map of artist becomes level json for POST, or stored level becomes Tiled map for editing.
+creator, game and number are kept if both formats have them, flags override them.
+return exit code
+*/
+func runConvert(args []string) int {
+	var (
+		err error
+
+		fs               *flag.FlagSet
+		in, out, cfgFile *string
+		creator, game    *string
+		number           *int64
+		cfg              *config.ConfType
+		level            model.LevelType
+	)
+
+	fs = flag.NewFlagSet("convert", flag.ContinueOnError)
+	in = fs.String("in", "", "level file: json level or codec format by extension (.txt is ascii, .tmx/.tmj is Tiled map, .png is pixel-art image)")
+	out = fs.String("out", "", "result file, format is chosen by extension the same way")
+	creator = fs.String("creator", "", "level's creator, overrides one of input file")
+	game = fs.String("game", "", "level's game, overrides one of input file")
+	number = fs.Int64("level", 0, "level's number, overrides one of input file")
+	cfgFile = fs.String("config", "", "config file with tile mapping of Tiled maps")
+
+	err = fs.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	if (*in == "") || (*out == "") {
+		fmt.Println("[error] -in and -out are required")
+		fs.Usage()
+		return 2
+	}
+
+	// only tile mapping is needed, so storage settings are not validated
+	cfg = &config.ConfType{}
+	if *cfgFile != "" {
+		cfg, err = config.ReadConfig(*cfgFile)
+		if err != nil {
+			fmt.Println("[error] read config:", err)
+			return 1
+		}
+	}
+
+	registerCodecs(cfg)
+
+	level, err = readLevelFile(*in)
+	if err != nil {
+		fmt.Println("[error] read level:", err)
+		return 1
+	}
+
+	if *creator != "" {
+		level.Creator = *creator
+	}
+
+	if *game != "" {
+		level.Game = *game
+	}
+
+	if *number != 0 {
+		level.Level = *number
+	}
+
+	err = writeLevelFile(*out, level)
+	if err != nil {
+		fmt.Println("[error] write level:", err)
+		return 1
+	}
+
+	fmt.Println("level is written to", *out)
+
+	return 0
+}
+
+/*
+validate level from file and store it, the same as POST request does, e.g. for old levels which exist only as images:
+go run . import -in level.png -creator designer -game labyrinth -level 1 [-config config.yml] [config flags]
+format is chosen by extension the same as for render input, creator, game and number flags override ones of file.
+storage, constraints and colors of images are taken from config the same as for service.
+return exit code
+*/
+func runImport(args []string) int {
+	var (
+		err, status error
+
+		fs            *flag.FlagSet
+		in            *string
+		creator, game *string
+		number        *int64
+		cfg           *config.ConfType
+		db            *sql.DB
+		storage       model.RepositoryType
+		level         model.LevelType
+		gameProfile   model.GameType
+		constraints   config.ConstraintsType
+		levelId       int64
+	)
+
+	fs = flag.NewFlagSet("import", flag.ContinueOnError)
+	in = fs.String("in", "", "level file: json level or codec format by extension (.txt is ascii, .tmx/.tmj is Tiled map, .png is pixel-art image)")
+	creator = fs.String("creator", "", "level's creator, overrides one of input file")
+	game = fs.String("game", "", "level's game, overrides one of input file")
+	number = fs.Int64("level", 0, "level's number, overrides one of input file")
+
+	// service config with its flags, storage is the same as service one
+	cfg, err = config.Load(fs, args, config.DefaultPath)
+	if err != nil {
+		fmt.Println("[error] config build:", err)
+		return 2
+	}
+
+	if *in == "" {
+		fmt.Println("[error] -in is required")
+		fs.Usage()
+		return 2
+	}
+
+	if cfg.Storage.Driver == model.StorageMemory {
+		fmt.Println("[error] in-memory storage loses imported level on exit")
+		return 2
+	}
+
+	registerCodecs(cfg)
+
+	level, err = readLevelFile(*in)
+	if err != nil {
+		fmt.Println("[error] read level:", err)
+		return 1
+	}
+
+	if *creator != "" {
+		level.Creator = *creator
+	}
+
+	if *game != "" {
+		level.Game = *game
+	}
+
+	if *number != 0 {
+		level.Level = *number
+	}
+
+	// images and character maps have no creator and game inside
+	if (level.Creator == "") || (level.Game == "") {
+		fmt.Println("[error] level has no creator or game, set them by -creator and -game")
+		return 2
+	}
+
+	storage, db = openStorage(cfg)
+	if storage == nil {
+		return 1
+	}
+
+	defer func() {
+		if err := db.Close(); err != nil {
+			fmt.Println("[error] clear memory db", err)
+		}
+	}()
+
+	// existing game may have its own constraints profile
+	gameProfile = model.GameType{Creator: level.Creator, Game: level.Game}
+
+	status = storage.LoadGame(&gameProfile)
+	if (status != nil) && (status != model.ErrNotFound) {
+		fmt.Println("[error] load game profile:", status.Error())
+		return 1
+	}
+
+	level.Profile = gameProfile.Profile
+	constraints = cfg.Constraints
+
+	status = level.Validate(constraints)
+	if status != nil {
+		fmt.Println("[error] level is not valid:", status.Error())
+		return 1
+	}
+
+	levelId = storage.StoreLevel(&level, constraints)
+	if levelId <= 0 {
+		fmt.Println("[error] storing level data failed")
+		return 1
+	}
+
+	fmt.Println("level is stored with id", levelId)
+
+	return 0
+}
+
+/*
+write creator's game with all its levels into archive file, storage is taken from config the same as for service:
+go run . export-game -creator designer -game labyrinth [-out labyrinth.zip] [-config config.yml] [config flags]
+return exit code
+*/
+func runExportGame(args []string) int {
+	var (
+		err, status error
+
+		fs            *flag.FlagSet
+		out           *string
+		creator, game *string
+		cfg           *config.ConfType
+		db            *sql.DB
+		storage       model.RepositoryType
+		gameArchive   model.GameArchiveType
+		file          *os.File
+	)
+
+	fs = flag.NewFlagSet("export-game", flag.ContinueOnError)
+	creator = fs.String("creator", "", "game's creator")
+	game = fs.String("game", "", "game's name")
+	out = fs.String("out", "", "archive file, game's name with .zip by default")
+
+	cfg, err = config.Load(fs, args, config.DefaultPath)
+	if err != nil {
+		fmt.Println("[error] config build:", err)
+		return 2
+	}
+
+	if (*creator == "") || (*game == "") {
+		fmt.Println("[error] -creator and -game are required")
+		fs.Usage()
+		return 2
+	}
+
+	if *out == "" {
+		*out = *game + archive.Extension
+	}
+
+	storage, db = openStorage(cfg)
+	if storage == nil {
+		return 1
+	}
+
+	if db != nil {
+		defer func() {
+			if err := db.Close(); err != nil {
+				fmt.Println("[error] clear memory db", err)
+			}
+		}()
+	}
+
+	gameArchive, status = archive.Export(storage, *creator, *game)
+	if status != nil {
+		fmt.Println("[error] export game:", status.Error())
+		return 1
+	}
+
+	file, err = os.Create(*out)
+	if err != nil {
+		fmt.Println("[error] create archive file:", err)
+		return 1
+	}
+
+	err = archive.Write(file, gameArchive, time.Now())
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		fmt.Println("[error] write archive file:", err)
+		return 1
+	}
+
+	fmt.Println("game with", len(gameArchive.Levels), "levels is written to", *out)
+
+	return 0
+}
+
+/*
+import game from archive file in single transaction, every level is validated with constraints of config first:
+go run . import-game -in labyrinth.zip [-conflict skip|overwrite|rename] [-config config.yml] [config flags]
+without -conflict import of existing game fails.
+return exit code
+*/
+func runImportGame(args []string) int {
+	var (
+		err, status error
+
+		fs          *flag.FlagSet
+		in          *string
+		conflict    *string
+		cfg         *config.ConfType
+		db          *sql.DB
+		storage     model.RepositoryType
+		input       []byte
+		gameArchive model.GameArchiveType
+		result      model.ImportResultType
+	)
+
+	fs = flag.NewFlagSet("import-game", flag.ContinueOnError)
+	in = fs.String("in", "", "archive file of game")
+	conflict = fs.String("conflict", model.ConflictFail, "what to do if game exists: skip, overwrite or rename")
+
+	cfg, err = config.Load(fs, args, config.DefaultPath)
+	if err != nil {
+		fmt.Println("[error] config build:", err)
+		return 2
+	}
+
+	if *in == "" {
+		fmt.Println("[error] -in is required")
+		fs.Usage()
+		return 2
+	}
+
+	if !model.IsConflictOption(*conflict) {
+		fmt.Println("[error] -conflict must be skip, overwrite or rename")
+		return 2
+	}
+
+	if cfg.Storage.Driver == model.StorageMemory {
+		fmt.Println("[error] in-memory storage loses imported game on exit")
+		return 2
+	}
+
+	input, err = ioutil.ReadFile(*in)
+	if err != nil {
+		fmt.Println("[error] read archive file:", err)
+		return 1
+	}
+
+	gameArchive, err = archive.Read(input, archive.LimitsType{Size: int64(cfg.Server.MaxBody) * archive.UnpackRatio, Side: cfg.Constraints.MaxSide()})
+	if err != nil {
+		fmt.Println("[error] read archive:", err)
+		return 1
+	}
+
+	if !cfg.Constraints.HasProfile(gameArchive.Profile) {
+		fmt.Printf("[error] constraints profile %q is not found in config\n", gameArchive.Profile)
+		return 1
+	}
+
+	status = gameArchive.Validate(cfg.Constraints)
+	if status != nil {
+		fmt.Println("[error] game archive is not valid:", status.Error())
+		return 1
+	}
+
+	storage, db = openStorage(cfg)
+	if storage == nil {
+		return 1
+	}
+
+	defer func() {
+		if err := db.Close(); err != nil {
+			fmt.Println("[error] clear memory db", err)
+		}
+	}()
+
+	result, status = storage.ImportGame(&gameArchive, *conflict, cfg.Constraints)
+	if status == model.ErrConflict {
+		fmt.Println("[error] game", gameArchive.Game, "of creator", gameArchive.Creator, "already exists, choose -conflict option")
+		return 1
+	}
+
+	if status != nil {
+		fmt.Println("[error] import game:", status.Error())
+		return 1
+	}
+
+	fmt.Println("game", result.Game, "of creator", result.Creator, "is", result.Status, "levels:", result.Levels)
+
+	return 0
+}
+
+// read level from file: .json is level json (the same as POST body), other extensions are codec formats.
+// creator, game and number are read too if format keeps them.
+// return level
+func readLevelFile(name string) (level model.LevelType, err error) {
+	var (
+		input      []byte
+		levelCodec codec.CodecType
+		meta       codec.MetaType
+	)
+
+	input, err = ioutil.ReadFile(name)
+	if err != nil {
+		return level, err
+	}
+
+	if fileFormat(name) == FormatJSON {
+		err = json.Unmarshal(input, &level)
+		return level, err
+	}
+
+	levelCodec, err = codec.ByName(fileFormat(name))
+	if err != nil {
+		return level, fmt.Errorf("%w %q, expected json or one of %v", err, fileFormat(name), codec.Names())
+	}
+
+	if metaCodec, ok := levelCodec.(codec.MetaCodecType); ok {
+		meta, level.Data, err = metaCodec.DecodeLevel(input)
+	} else {
+		level.Data, err = levelCodec.Decode(input)
+	}
+
+	level.Creator = meta.Creator
+	level.Game = meta.Game
+	level.Level = meta.Level
+
+	return level, err
+}
+
+// write level to file in format which is chosen by extension, the same as for readLevelFile
+func writeLevelFile(name string, level model.LevelType) (err error) {
+	var (
+		output     []byte
+		levelCodec codec.CodecType
+	)
+
+	if fileFormat(name) == FormatJSON {
+		output, err = json.MarshalIndent(level, "", "  ")
+	} else {
+		levelCodec, err = codec.ByName(fileFormat(name))
+		if err != nil {
+			return fmt.Errorf("%w %q, expected json or one of %v", err, fileFormat(name), codec.Names())
+		}
+
+		if metaCodec, ok := levelCodec.(codec.MetaCodecType); ok {
+			output, err = metaCodec.EncodeLevel(codec.MetaType{Creator: level.Creator, Game: level.Game, Level: level.Level}, level.Data)
+		} else {
+			output, err = levelCodec.Encode(level.Data)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(name, output, 0644)
+}
+
+// return level format of file by its extension, text files are character maps
+func fileFormat(name string) (format string) {
+	format = strings.TrimPrefix(filepath.Ext(name), ".")
+	if format == "txt" {
+		return codec.ASCIIType{}.Name()
+	}
+
+	return format
+}
@@ -4,13 +4,32 @@ package config
 import (
 	"fmt"
 	"gopkg.in/yaml.v2"
+	"io"
 	"os"
 )
 
 const (
-	DefaultPath = "" // default prefix for path to real config file
+	DefaultPath = ""           // default prefix for path to real config file
+	FileName    = "config.yml" // name of config file in prefix path
+
+	DriverPostgres = "postgres" // storage driver - PostgreSQL db, default one
+	DriverSQLite   = "sqlite"   // storage driver - embedded SQLite db file, for local level-design sessions
+	DriverMemory   = "memory"   // storage driver - process memory, data is lost on restart
 )
 
+// subtype for config, describing http server parameters. max body is size limit of request body in bytes
+type ServerType struct {
+	Port    int `yaml:"port"`
+	MaxBody int `yaml:"max_body"`
+}
+
+// subtype for config, describing storage parameters. driver is "postgres" (default), "sqlite" or "memory",
+// file is path to db file for sqlite driver
+type StorageType struct {
+	Driver string `yaml:"driver"`
+	File   string `yaml:"file"`
+}
+
 // subtype for config, describing dsn parameters
 type DSNType struct {
 	Host   string `yaml:"host"`
@@ -20,56 +39,186 @@ type DSNType struct {
 	Pass   string `yaml:"pass"`
 }
 
-// subtype for config, describing constraint parameters
+// subtype for config, describing constraint parameters. named profiles override whole constraints for games
+// which are assigned to them, profile's fields which are not set get default values
 type ConstraintsType struct {
 	Dimension struct {
-		Max int `yaml:"max"`
+		Max   int `yaml:"max"`
+		Width struct {
+			Min int `yaml:"min"`
+			Max int `yaml:"max"`
+		} `yaml:"width"`
+		Height struct {
+			Min int `yaml:"min"`
+			Max int `yaml:"max"`
+		} `yaml:"height"`
 	} `yaml:"dimension"`
 	Point struct {
-		Min int `yaml:"min"`
-		Max int `yaml:"max"`
+		Min     int   `yaml:"min"`
+		Max     int   `yaml:"max"`
+		Allowed []int `yaml:"allowed"`
 	} `yaml:"point"`
+	Hero struct {
+		Health int `yaml:"health"`
+	} `yaml:"hero"`
+	Damage DamageType `yaml:"damage"`
+	Rules  struct {
+		SingleHero  bool `yaml:"single_hero"`
+		RequireExit bool `yaml:"require_exit"`
+		WallBorder  bool `yaml:"wall_border"`
+		TrapDensity struct {
+			Check bool `yaml:"check"`
+			Max   int  `yaml:"max"` // percent of traps among cells which are not walls
+		} `yaml:"trap_density"`
+		IsolatedAreas struct {
+			Check bool `yaml:"check"`
+			Max   int  `yaml:"max"` // count of areas which hero can't reach
+		} `yaml:"isolated_areas"`
+	} `yaml:"rules"`
+	RequireSolvable bool                       `yaml:"require_solvable"`
+	Profiles        map[string]ConstraintsType `yaml:"profiles"`
+}
+
+// choose constraints of named profile, empty name means default constraints.
+// return constraints of profile and true, or default constraints and false if profile is unknown
+func (obj ConstraintsType) Profile(name string) (constraints ConstraintsType, ok bool) {
+	if name == "" {
+		return obj, true
+	}
+
+	constraints, ok = obj.Profiles[name]
+	if !ok {
+		return obj, false
+	}
+
+	return constraints, true
+}
+
+// check that profile exists, empty name is default constraints.
+// return true if profile can be assigned to game
+func (obj ConstraintsType) HasProfile(name string) bool {
+	var (
+		ok bool
+	)
+
+	_, ok = obj.Profile(name)
+
+	return ok
+}
+
+// find the biggest count of lines or length of line allowed by default constraints or any profile.
+// return max side of level
+func (obj ConstraintsType) MaxSide() (side int) {
+	side = obj.Dimension.Width.Max
+	if obj.Dimension.Height.Max > side {
+		side = obj.Dimension.Height.Max
+	}
+
+	for _, profile := range obj.Profiles {
+		if profile.MaxSide() > side {
+			side = profile.MaxSide()
+		}
+	}
+
+	return side
+}
+
+// subtype for constraints, describing damage of each kind of trap. damage which isn't set is default one, so
+// explicit zero (harmless trap) is kept as it is
+type DamageType struct {
+	Pit   *int `yaml:"pit"`
+	Arrow *int `yaml:"arrow"`
+}
+
+// return damage of pit and arrow traps, default damage for ones which are not set
+func (obj DamageType) Values() (pit, arrow int) {
+	pit, arrow = DefaultDamage, DefaultDamage
+
+	if obj.Pit != nil {
+		pit = *obj.Pit
+	}
+
+	if obj.Arrow != nil {
+		arrow = *obj.Arrow
+	}
+
+	return pit, arrow
+}
+
+// return damage values instead of pointers, so printed constraints are readable
+func (obj DamageType) String() string {
+	var (
+		pit, arrow int
+	)
+
+	pit, arrow = obj.Values()
+
+	return fmt.Sprintf("{%d %d}", pit, arrow)
+}
+
+// subtype for config, describing import and export of Tiled maps. tiles map global tile id into level point,
+// empty mapping means tileset where tile id is point + 1 and empty tile is open one.
+// tileset is external tileset file which exported maps refer to
+type TiledType struct {
+	Tiles   map[int]int `yaml:"tiles"`
+	Tileset string      `yaml:"tileset"`
+}
+
+// subtype for config, describing import and export of pixel-art images. colors map pixel color as #rrggbb into
+// level point, empty mapping means colors of level images
+type BitmapType struct {
+	Colors map[string]int `yaml:"colors"`
 }
 
 // describing config structure
 type ConfType struct {
+	Server      ServerType      `yaml:"server"`
+	Storage     StorageType     `yaml:"storage"`
 	Database    DSNType         `yaml:"db"`
 	Constraints ConstraintsType `yaml:"constraints"`
+	Tiled       TiledType       `yaml:"tiled"`
+	Bitmap      BitmapType      `yaml:"bitmap"`
+
+	source *sourceType
 }
 
 /*
-open yaml file, read and decode to config structure.
-return config instance
+open yaml file in prefix path, read and decode to config structure, apply defaults and validate.
+return config instance, or error listing every problem
 */
-func BuildConfig(path string) (cfg *ConfType) {
-	var (
-		err error
+func BuildConfig(path string) (cfg *ConfType, err error) {
+	return (&sourceType{file: path + FileName}).build()
+}
 
+/*
+open yaml file by full name, read and decode to config structure. fields which file doesn't set stay empty.
+return config instance or error
+*/
+func ReadConfig(name string) (cfg *ConfType, err error) {
+	var (
 		file    *os.File
 		decoder *yaml.Decoder
 	)
 
 	// open config file
-	file, err = os.Open(path + "config.yml")
+	file, err = os.Open(name)
 	if err != nil {
-		fmt.Println("error [open config file]:", err)
-		return nil
+		return nil, fmt.Errorf("open config file: %w", err)
 	}
 
 	defer func() {
-		err = file.Close()
-		if err != nil {
-			fmt.Println("error [close config file]:", err)
+		if closeErr := file.Close(); closeErr != nil {
+			fmt.Println("error [close config file]:", closeErr)
 		}
 	}()
 
-	// decode opened file into config structure
+	// decode opened file into config structure, empty file is valid one
+	cfg = &ConfType{}
 	decoder = yaml.NewDecoder(file)
-	err = decoder.Decode(&cfg)
-	if err != nil {
-		fmt.Println("error [read config file]:", err)
-		return nil
+	err = decoder.Decode(cfg)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("read config file %s: %w", name, err)
 	}
 
-	return cfg
+	return cfg, nil
 }
@@ -0,0 +1,306 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	var (
+		err error
+
+		dir string
+		cfg *ConfType
+	)
+
+	dir = t.TempDir()
+
+	err = os.WriteFile(filepath.Join(dir, FileName), []byte("db:\n  host: file\n  port: \"5432\"\n  dbname: greenjade\n  user: greenjade\nconstraints:\n  point:\n    max: 4\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(EnvName("db.host"), "env")
+	t.Setenv(EnvName("constraints.point.max"), "5")
+
+	cfg, err = Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-constraints.point.max", "6", "-p", "8080", "-constraints.require_solvable"}, dir+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Database.Port != "5432" {
+		t.Errorf("expected db port from file, got %q", cfg.Database.Port)
+	}
+
+	if cfg.Database.Host != "env" {
+		t.Errorf("expected db host from environment, got %q", cfg.Database.Host)
+	}
+
+	if cfg.Constraints.Point.Max != 6 {
+		t.Errorf("expected point max from flag, got %d", cfg.Constraints.Point.Max)
+	}
+
+	if (cfg.Server.Port != 8080) || !cfg.Constraints.RequireSolvable {
+		t.Errorf("expected port and require_solvable from flags, got %d and %v", cfg.Server.Port, cfg.Constraints.RequireSolvable)
+	}
+}
+
+func TestLoadConfigFlag(t *testing.T) {
+	var (
+		err error
+
+		name string
+		cfg  *ConfType
+	)
+
+	name = filepath.Join(t.TempDir(), "custom.yml")
+
+	err = os.WriteFile(name, []byte("storage:\n  driver: memory\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err = Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-config", name}, t.TempDir()+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (cfg.Storage.Driver != "memory") || (cfg.Server.Port != DefaultPort) {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	// explicitly chosen file must exist
+	cfg, err = Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-config", name + ".missing"}, "")
+	if (cfg != nil) || (err == nil) {
+		t.Error("expected error for missing config file")
+	}
+
+	// invalid value is rejected
+	t.Setenv(EnvName("server.port"), "port")
+
+	cfg, err = Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-config", name}, "")
+	if (cfg != nil) || (err == nil) {
+		t.Error("expected error for invalid environment value")
+	}
+}
+
+func TestValidateDefaults(t *testing.T) {
+	var (
+		err error
+
+		cfg      *ConfType
+		problems *ConfigErrorType
+		ok       bool
+	)
+
+	// empty config of example file gets defaults, but dsn can't be guessed
+	cfg = &ConfType{}
+	cfg.SetDefaults()
+
+	if (cfg.Server.Port != DefaultPort) || (cfg.Storage.Driver != DriverPostgres) || (cfg.Database.Port != DefaultDBPort) {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+
+	if (cfg.Constraints.Dimension.Width.Max != DefaultDimensionMax) || (cfg.Constraints.Dimension.Height.Min != DefaultDimensionMin) || (cfg.Constraints.Point.Max != DefaultPointMax) || (cfg.Constraints.Hero.Health != DefaultHeroHealth) || (cfg.Constraints.Damage.String() != "{1 1}") {
+		t.Errorf("unexpected constraints defaults: %+v", cfg.Constraints)
+	}
+
+	err = cfg.Validate()
+	problems, ok = err.(*ConfigErrorType)
+	if !ok || (len(problems.Problems) != 3) {
+		t.Errorf("expected 3 problems (db host, dbname, user), got %v", err)
+	}
+
+	// every problem is reported at once
+	cfg = &ConfType{Storage: StorageType{Driver: "mongo"}}
+	cfg.Server.Port = -1
+	cfg.Server.MaxBody = -1
+	cfg.Constraints.Dimension.Max = -1
+	cfg.Constraints.Point.Min = 3
+	cfg.Constraints.Point.Max = 2
+	cfg.Constraints.Hero.Health = -1
+	cfg.Constraints.Damage.Pit = new(int)
+	*cfg.Constraints.Damage.Pit = -1
+
+	err = cfg.Validate()
+	problems, ok = err.(*ConfigErrorType)
+	if !ok || (len(problems.Problems) != 9) {
+		t.Errorf("expected 9 problems (including width and height min without defaults), got %v", err)
+	}
+
+	cfg = &ConfType{Storage: StorageType{Driver: DriverMemory}}
+	cfg.SetDefaults()
+	cfg.Constraints.Hero.Health = MaxHeroHealth + 1
+
+	err = cfg.Validate()
+	problems, ok = err.(*ConfigErrorType)
+	if !ok || (len(problems.Problems) != 1) || !strings.Contains(problems.Problems[0], "hero.health") {
+		t.Errorf("expected problem of too big hero health, got %v", err)
+	}
+
+	cfg = &ConfType{Storage: StorageType{Driver: DriverMemory}}
+	cfg.SetDefaults()
+
+	err = cfg.Validate()
+	if err != nil {
+		t.Errorf("expected valid config, got %v", err)
+	}
+}
+
+func TestDamage(t *testing.T) {
+	var (
+		err error
+
+		name       string
+		cfg        *ConfType
+		pit, arrow int
+	)
+
+	name = filepath.Join(t.TempDir(), "custom.yml")
+
+	// explicit zero is kept, damage which isn't set is default one
+	err = os.WriteFile(name, []byte("storage:\n  driver: memory\nconstraints:\n  damage:\n    pit: 0\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err = Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-config", name}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pit, arrow = cfg.Constraints.Damage.Values()
+	if (pit != 0) || (arrow != DefaultDamage) {
+		t.Errorf("expected harmless pit and default arrow, got %d %d", pit, arrow)
+	}
+
+	cfg, err = Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-config", name, "-constraints.damage.pit", "2", "-constraints.damage.arrow", "0"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pit, arrow = cfg.Constraints.Damage.Values()
+	if (pit != 2) || (arrow != 0) {
+		t.Errorf("expected damage of flags, got %d %d", pit, arrow)
+	}
+
+	_, err = Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-config", name, "-constraints.damage.arrow", "-1"}, "")
+	if (err == nil) || !strings.Contains(err.Error(), "constraints.damage.arrow -1 must not be negative") {
+		t.Errorf("expected problem of negative damage, got %v", err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	var (
+		err error
+
+		name        string
+		cfg, actual *ConfType
+	)
+
+	name = filepath.Join(t.TempDir(), "custom.yml")
+
+	err = os.WriteFile(name, []byte("storage:\n  driver: memory\nconstraints:\n  dimension:\n    max: 10\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err = Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-config", name, "-constraints.hero.health", "3"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(name, []byte("storage:\n  driver: memory\nconstraints:\n  dimension:\n    max: 20\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// file is re-read, flags of start keep priority
+	actual, err = cfg.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (actual.Constraints.Dimension.Max != 20) || (actual.Constraints.Hero.Health != 3) {
+		t.Errorf("unexpected reloaded constraints: %+v", actual.Constraints)
+	}
+
+	// invalid file is rejected, previous config is not changed
+	err = os.WriteFile(name, []byte("storage:\n  driver: memory\nconstraints:\n  dimension:\n    max: -1\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err = cfg.Reload()
+	if (actual != nil) || (err == nil) {
+		t.Error("expected error for invalid config")
+	}
+
+	if cfg.Constraints.Dimension.Max != 10 {
+		t.Errorf("previous config is changed: %+v", cfg.Constraints)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	var (
+		err error
+
+		name    string
+		cfg     *ConfType
+		profile ConstraintsType
+		ok      bool
+	)
+
+	name = filepath.Join(t.TempDir(), "custom.yml")
+
+	err = os.WriteFile(name, []byte(`storage:
+  driver: memory
+constraints:
+  dimension:
+    max: 100
+  profiles:
+    puzzle:
+      dimension:
+        max: 10
+      point:
+        max: 5
+        allowed: [0, 1, 4, 5]
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err = Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-config", name}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// unset fields of profile get defaults, unknown profile means default constraints
+	profile, ok = cfg.Constraints.Profile("puzzle")
+	if !ok || (profile.Dimension.Max != 10) || (profile.Hero.Health != DefaultHeroHealth) {
+		t.Errorf("unexpected puzzle profile: %v %+v", ok, profile)
+	}
+
+	profile, ok = cfg.Constraints.Profile("dungeon")
+	if ok || (profile.Dimension.Max != 100) {
+		t.Errorf("expected default constraints for unknown profile, got %v %+v", ok, profile)
+	}
+
+	if !cfg.Constraints.HasProfile("puzzle") || !cfg.Constraints.HasProfile("") || cfg.Constraints.HasProfile("dungeon") {
+		t.Error("unexpected profiles list")
+	}
+
+	// profile is validated as well as default constraints
+	err = os.WriteFile(name, []byte("storage:\n  driver: memory\nconstraints:\n  profiles:\n    puzzle:\n      point:\n        max: 3\n        allowed: [4]\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = cfg.Reload()
+	if (err == nil) || !strings.Contains(err.Error(), "constraints.profiles.puzzle.point.allowed") {
+		t.Errorf("expected error for profile, got %v", err)
+	}
+}
@@ -0,0 +1,272 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	EnvPrefix = "GREENJADE"           // prefix of environment variables overriding config fields
+	EnvConfig = EnvPrefix + "_CONFIG" // environment variable with path to config file
+)
+
+// value of config field which is set from outside of config file
+type overrideType struct {
+	name  string
+	value string
+}
+
+// flag value which only remembers what was passed, fields are set later to keep precedence order
+type flagValueType struct {
+	name      string
+	isBool    bool
+	overrides *[]overrideType
+}
+
+func (obj *flagValueType) String() string {
+	return ""
+}
+
+func (obj *flagValueType) Set(value string) error {
+	*obj.overrides = append(*obj.overrides, overrideType{name: obj.name, value: value})
+	return nil
+}
+
+func (obj *flagValueType) IsBoolFlag() bool {
+	return obj.isBool
+}
+
+// sources of config, they are kept to build config again on reload
+type sourceType struct {
+	path      string
+	file      string
+	env       bool
+	overrides []overrideType
+}
+
+/*
+register flag for every config field (-db.host, -constraints.point.max and so on), -config flag and -p as alias
+of -server.port, parse command-line arguments and build config. values are taken in order of precedence
+(each next source overrides previous one):
+ 1. config file: -config flag, GREENJADE_CONFIG variable or config.yml in prefix path
+ 2. environment variables: GREENJADE_DB_HOST, GREENJADE_CONSTRAINTS_POINT_MAX and so on
+ 3. command-line flags
+
+fields which are still not set get default values, then config is validated.
+return config instance, or error listing every problem
+*/
+func Load(fs *flag.FlagSet, args []string, path string) (cfg *ConfType, err error) {
+	var (
+		source *sourceType
+		file   *string
+	)
+
+	source = &sourceType{path: path, env: true}
+
+	file = fs.String("config", "", "path to config file (default "+path+FileName+")")
+
+	walkFields(reflect.ValueOf(&ConfType{}).Elem(), "", func(key string, field reflect.Value) {
+		fs.Var(&flagValueType{name: key, isBool: field.Kind() == reflect.Bool, overrides: &source.overrides}, key, "override "+key+" config field")
+	})
+	fs.Var(&flagValueType{name: "server.port", overrides: &source.overrides}, "p", "service port, alias of -server.port")
+
+	err = fs.Parse(args)
+	if err != nil {
+		return nil, fmt.Errorf("parse command-line flags: %w", err)
+	}
+
+	source.file = *file
+
+	return source.build()
+}
+
+/*
+build config once more from the same sources: config file is re-read, environment and flags of start are applied.
+return new config instance, or error listing every problem (current config stays untouched)
+*/
+func (obj *ConfType) Reload() (cfg *ConfType, err error) {
+	if obj.source == nil {
+		return nil, fmt.Errorf("config has no sources to reload from")
+	}
+
+	return obj.source.build()
+}
+
+// read config file, apply environment and flags, defaults and validate.
+// return config instance, or error listing every problem
+func (obj *sourceType) build() (cfg *ConfType, err error) {
+	var (
+		problems ConfigErrorType
+		name     string
+	)
+
+	// choose config file
+	name = obj.path + FileName
+	if value, ok := os.LookupEnv(EnvConfig); obj.env && ok && (value != "") {
+		name = value
+	}
+
+	if obj.file != "" {
+		name = obj.file
+	}
+
+	// config file is optional only if it isn't chosen explicitly, so everything can be set by environment
+	_, err = os.Stat(name)
+	if obj.env && (name == obj.path+FileName) && os.IsNotExist(err) {
+		fmt.Println("config file", name, "not found, use environment and flags only")
+		cfg = &ConfType{}
+	} else {
+		cfg, err = ReadConfig(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// environment has priority over file, flags have priority over environment
+	if obj.env {
+		applyEnv(cfg, &problems)
+	}
+
+	for _, override := range obj.overrides {
+		setField(cfg, override.name, override.value, &problems)
+	}
+
+	if problems.status() != nil {
+		return nil, problems.status()
+	}
+
+	cfg.SetDefaults()
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.source = obj
+
+	return cfg, nil
+}
+
+// override config fields by non-empty environment variables, variable name is built from yaml keys of field:
+// db.host is GREENJADE_DB_HOST. invalid values are registered as problems
+func applyEnv(cfg *ConfType, problems *ConfigErrorType) {
+	walkFields(reflect.ValueOf(cfg).Elem(), "", func(key string, field reflect.Value) {
+		var (
+			value string
+		)
+
+		value = os.Getenv(EnvName(key))
+		if value == "" {
+			return
+		}
+
+		setValue(field, EnvName(key), value, problems)
+	})
+}
+
+// return name of environment variable for config field key (db.host is GREENJADE_DB_HOST)
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// find config field by key and set it from string value of flag. invalid values are registered as problems
+func setField(cfg *ConfType, key, value string, problems *ConfigErrorType) {
+	walkFields(reflect.ValueOf(cfg).Elem(), "", func(fieldKey string, field reflect.Value) {
+		if fieldKey == key {
+			setValue(field, "-"+key, value, problems)
+		}
+	})
+}
+
+// convert string value into type of config field and set it, source is name of variable or flag for problem message
+func setValue(field reflect.Value, source, value string, problems *ConfigErrorType) {
+	var (
+		err error
+
+		number  int
+		boolean bool
+		list    reflect.Value
+	)
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+
+	case reflect.Int:
+		number, err = strconv.Atoi(value)
+		if err != nil {
+			problems.add("%s %q is not integer", source, value)
+			return
+		}
+
+		field.SetInt(int64(number))
+
+	case reflect.Ptr:
+		// optional integer, value which is set from outside is explicit even if it's zero
+		number, err = strconv.Atoi(value)
+		if err != nil {
+			problems.add("%s %q is not integer", source, value)
+			return
+		}
+
+		field.Set(reflect.ValueOf(&number))
+
+	case reflect.Bool:
+		boolean, err = strconv.ParseBool(value)
+		if err != nil {
+			problems.add("%s %q is not boolean", source, value)
+			return
+		}
+
+		field.SetBool(boolean)
+
+	case reflect.Slice:
+		// list of integers is comma separated: 0,1,4
+		list = reflect.MakeSlice(field.Type(), 0, 0)
+		for _, item := range strings.Split(value, ",") {
+			number, err = strconv.Atoi(strings.TrimSpace(item))
+			if err != nil {
+				problems.add("%s %q is not comma separated list of integers", source, value)
+				return
+			}
+
+			list = reflect.Append(list, reflect.ValueOf(number))
+		}
+
+		field.Set(list)
+	}
+}
+
+// walk over nested config structures and call visit for every leaf field with key built from yaml tags (db.host)
+func walkFields(value reflect.Value, prefix string, visit func(key string, field reflect.Value)) {
+	for i := 0; i < value.NumField(); i++ {
+		var (
+			key string
+		)
+
+		// unexported fields are not part of config file
+		if value.Type().Field(i).PkgPath != "" {
+			continue
+		}
+
+		key = strings.Split(value.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch value.Field(i).Kind() {
+		case reflect.Struct:
+			walkFields(value.Field(i), key, visit)
+			continue
+		case reflect.Map:
+			// profiles can be set only in config file
+			continue
+		}
+
+		visit(key, value.Field(i))
+	}
+}
@@ -0,0 +1,251 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultPort         = 9080        // default service port
+	DefaultMaxBody      = 8 << 20     // default size limit of request body in bytes, it fits archive of big game
+	DefaultDBPort       = "5432"      // default PostgreSQL port
+	DefaultSQLiteFile   = "levels.db" // default sqlite db file
+	DefaultDimensionMax = 100         // default max count of lines and points in line
+	DefaultDimensionMin = 1           // default min count of lines and points in line, level can't be empty
+	DefaultPointMax     = 5           // default max point value, covers every essence up to exit marker
+	DefaultHeroHealth   = 1           // default hero's health, with default damage any trap is lethal
+	MaxHeroHealth       = 100         // max hero's health, analysis keeps state for each health value of each point
+	DefaultDamage       = 1           // default damage of each kind of trap
+)
+
+// error of config validation, it lists every found problem
+type ConfigErrorType struct {
+	Problems []string
+}
+
+// return all problems as single message
+func (obj *ConfigErrorType) Error() string {
+	return "invalid config: " + strings.Join(obj.Problems, "; ")
+}
+
+// register one more problem
+func (obj *ConfigErrorType) add(format string, args ...interface{}) {
+	obj.Problems = append(obj.Problems, fmt.Sprintf(format, args...))
+}
+
+// return nil if there are no problems, so caller gets untyped nil error
+func (obj *ConfigErrorType) status() error {
+	if len(obj.Problems) == 0 {
+		return nil
+	}
+
+	return obj
+}
+
+// fill fields which are not set (have zero value) and can't be zero by default values
+func (obj *ConfType) SetDefaults() {
+	if obj.Server.Port == 0 {
+		obj.Server.Port = DefaultPort
+	}
+
+	if obj.Server.MaxBody == 0 {
+		obj.Server.MaxBody = DefaultMaxBody
+	}
+
+	if obj.Storage.Driver == "" {
+		obj.Storage.Driver = DriverPostgres
+	}
+
+	if (obj.Storage.Driver == DriverSQLite) && (obj.Storage.File == "") {
+		obj.Storage.File = DefaultSQLiteFile
+	}
+
+	if (obj.Storage.Driver == DriverPostgres) && (obj.Database.Port == "") {
+		obj.Database.Port = DefaultDBPort
+	}
+
+	obj.Constraints.setDefaults()
+
+	// map holds copies of profiles, so each one is replaced
+	for name, profile := range obj.Constraints.Profiles {
+		profile.setDefaults()
+		obj.Constraints.Profiles[name] = profile
+	}
+}
+
+// fill constraints which are not set (have zero value) by default values
+func (obj *ConstraintsType) setDefaults() {
+	if obj.Dimension.Max == 0 {
+		obj.Dimension.Max = DefaultDimensionMax
+	}
+
+	// common max is used for width and height which are not set
+	if obj.Dimension.Width.Max == 0 {
+		obj.Dimension.Width.Max = obj.Dimension.Max
+	}
+
+	if obj.Dimension.Height.Max == 0 {
+		obj.Dimension.Height.Max = obj.Dimension.Max
+	}
+
+	if obj.Dimension.Width.Min == 0 {
+		obj.Dimension.Width.Min = DefaultDimensionMin
+	}
+
+	if obj.Dimension.Height.Min == 0 {
+		obj.Dimension.Height.Min = DefaultDimensionMin
+	}
+
+	// zero range allows only open tiles, so it isn't set
+	if (obj.Point.Min == 0) && (obj.Point.Max == 0) {
+		obj.Point.Max = DefaultPointMax
+	}
+
+	if obj.Hero.Health == 0 {
+		obj.Hero.Health = DefaultHeroHealth
+	}
+}
+
+// check ranges and required fields of config.
+// return nil or error listing every problem
+func (obj *ConfType) Validate() (status error) {
+	var (
+		problems ConfigErrorType
+	)
+
+	if (obj.Server.Port < 1) || (obj.Server.Port > 65535) {
+		problems.add("server.port %d is out of range 1..65535", obj.Server.Port)
+	}
+
+	if obj.Server.MaxBody < 1 {
+		problems.add("server.max_body %d must be greater than 0", obj.Server.MaxBody)
+	}
+
+	switch obj.Storage.Driver {
+	case DriverPostgres:
+		// every dsn field except password is required
+		for _, field := range []struct{ key, value string }{
+			{"db.host", obj.Database.Host},
+			{"db.port", obj.Database.Port},
+			{"db.dbname", obj.Database.DBName},
+			{"db.user", obj.Database.User},
+		} {
+			if field.value == "" {
+				problems.add("%s is empty", field.key)
+			}
+		}
+
+		if _, err := strconv.Atoi(obj.Database.Port); (obj.Database.Port != "") && (err != nil) {
+			problems.add("db.port %q is not a number", obj.Database.Port)
+		}
+
+	case DriverSQLite:
+		if obj.Storage.File == "" {
+			problems.add("storage.file is empty")
+		}
+
+	case DriverMemory:
+
+	default:
+		problems.add("storage.driver %q is unknown, expected %s, %s or %s", obj.Storage.Driver, DriverPostgres, DriverSQLite, DriverMemory)
+	}
+
+	obj.Constraints.validate("constraints", &problems)
+
+	for name, profile := range obj.Constraints.Profiles {
+		if name == "" {
+			problems.add("constraints.profiles has profile with empty name")
+		}
+
+		if len(profile.Profiles) > 0 {
+			problems.add("constraints.profiles.%s can't have nested profiles", name)
+		}
+
+		profile.validate("constraints.profiles."+name, &problems)
+	}
+
+	for gid, point := range obj.Tiled.Tiles {
+		if gid < 0 {
+			problems.add("tiled.tiles has negative tile id %d", gid)
+		}
+
+		if (point < obj.Constraints.Point.Min) || (point > obj.Constraints.Point.Max) {
+			problems.add("tiled.tiles.%d point %d is out of range %d..%d", gid, point, obj.Constraints.Point.Min, obj.Constraints.Point.Max)
+		}
+	}
+
+	for name, point := range obj.Bitmap.Colors {
+		if _, err := strconv.ParseUint(strings.TrimPrefix(name, "#"), 16, 32); (len(name) != 7) || (name[0] != '#') || (err != nil) {
+			problems.add("bitmap.colors has color %q, expected #rrggbb", name)
+		}
+
+		if (point < obj.Constraints.Point.Min) || (point > obj.Constraints.Point.Max) {
+			problems.add("bitmap.colors.%s point %d is out of range %d..%d", name, point, obj.Constraints.Point.Min, obj.Constraints.Point.Max)
+		}
+	}
+
+	return problems.status()
+}
+
+// check ranges of constraints, prefix is path to constraints in config for problem message
+func (obj *ConstraintsType) validate(prefix string, problems *ConfigErrorType) {
+	if obj.Dimension.Max < 1 {
+		problems.add("%s.dimension.max %d must be greater than 0", prefix, obj.Dimension.Max)
+	}
+
+	if obj.Dimension.Width.Min < 1 {
+		problems.add("%s.dimension.width.min %d must be greater than 0", prefix, obj.Dimension.Width.Min)
+	}
+
+	if obj.Dimension.Width.Min > obj.Dimension.Width.Max {
+		problems.add("%s.dimension.width.min %d is greater than %s.dimension.width.max %d", prefix, obj.Dimension.Width.Min, prefix, obj.Dimension.Width.Max)
+	}
+
+	if obj.Dimension.Height.Min < 1 {
+		problems.add("%s.dimension.height.min %d must be greater than 0", prefix, obj.Dimension.Height.Min)
+	}
+
+	if obj.Dimension.Height.Min > obj.Dimension.Height.Max {
+		problems.add("%s.dimension.height.min %d is greater than %s.dimension.height.max %d", prefix, obj.Dimension.Height.Min, prefix, obj.Dimension.Height.Max)
+	}
+
+	if obj.Point.Min < 0 {
+		problems.add("%s.point.min %d must not be negative", prefix, obj.Point.Min)
+	}
+
+	if obj.Point.Min > obj.Point.Max {
+		problems.add("%s.point.min %d is greater than %s.point.max %d", prefix, obj.Point.Min, prefix, obj.Point.Max)
+	}
+
+	if obj.Hero.Health < 1 {
+		problems.add("%s.hero.health %d must be greater than 0", prefix, obj.Hero.Health)
+	}
+
+	if obj.Hero.Health > MaxHeroHealth {
+		problems.add("%s.hero.health %d must not be greater than %d", prefix, obj.Hero.Health, MaxHeroHealth)
+	}
+
+	// damage which isn't set is default one, explicit zero makes trap harmless
+	if (obj.Damage.Pit != nil) && (*obj.Damage.Pit < 0) {
+		problems.add("%s.damage.pit %d must not be negative", prefix, *obj.Damage.Pit)
+	}
+
+	if (obj.Damage.Arrow != nil) && (*obj.Damage.Arrow < 0) {
+		problems.add("%s.damage.arrow %d must not be negative", prefix, *obj.Damage.Arrow)
+	}
+
+	if (obj.Rules.TrapDensity.Max < 0) || (obj.Rules.TrapDensity.Max > 100) {
+		problems.add("%s.rules.trap_density.max %d is out of range 0..100", prefix, obj.Rules.TrapDensity.Max)
+	}
+
+	if obj.Rules.IsolatedAreas.Max < 0 {
+		problems.add("%s.rules.isolated_areas.max %d must not be negative", prefix, obj.Rules.IsolatedAreas.Max)
+	}
+
+	for _, point := range obj.Point.Allowed {
+		if (point < obj.Point.Min) || (point > obj.Point.Max) {
+			problems.add("%s.point.allowed value %d is out of range %d..%d", prefix, point, obj.Point.Min, obj.Point.Max)
+		}
+	}
+}
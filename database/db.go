@@ -27,7 +27,13 @@ func OpenDB(cfg config.DSNType) (db *sql.DB) {
 
 	err = db.Ping()
 	if err != nil {
-		panic(err)
+		fmt.Println("error [ping db]:", err)
+
+		if err = db.Close(); err != nil {
+			fmt.Println("error [close db]:", err)
+		}
+
+		return nil
 	}
 
 	return db
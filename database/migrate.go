@@ -0,0 +1,204 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	DialectPostgres = "postgres" // migrations for PostgreSQL
+	DialectSQLite   = "sqlite"   // migrations for SQLite
+)
+
+//go:embed migrations
+var migrationsFS embed.FS
+
+// structure describe single schema migration, version is taken from file name prefix (0001_init.sql)
+type MigrationType struct {
+	Version int
+	Name    string
+	Query   string
+}
+
+// read embedded migrations for specific db dialect.
+// return migrations ordered by version or nil on error
+func ReadMigrations(dialect string) (migrations []MigrationType) {
+	var (
+		err error
+
+		entries []fs.DirEntry
+	)
+
+	entries, err = migrationsFS.ReadDir(path.Join("migrations", dialect))
+	if err != nil {
+		fmt.Println("error [read migrations]:", err)
+		return nil
+	}
+
+	for _, entry := range entries {
+		var (
+			migration MigrationType
+			query     []byte
+			parts     []string
+		)
+
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		parts = strings.SplitN(strings.TrimSuffix(entry.Name(), ".sql"), "_", 2)
+
+		migration.Version, err = strconv.Atoi(parts[0])
+		if (err != nil) || (len(parts) != 2) {
+			fmt.Println("error [parse migration name]:", entry.Name())
+			return nil
+		}
+
+		query, err = migrationsFS.ReadFile(path.Join("migrations", dialect, entry.Name()))
+		if err != nil {
+			fmt.Println("error [read migration]:", err)
+			return nil
+		}
+
+		migration.Name = parts[1]
+		migration.Query = string(query)
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations
+}
+
+// create schema_migrations table (if it needs) and apply pending migrations in order, each one in its own transaction.
+// return count of applied migrations, -1 on error
+func Migrate(db *sql.DB, dialect string) (applied int) {
+	var (
+		err error
+
+		migrations []MigrationType
+		versions   map[int]bool
+	)
+
+	migrations = ReadMigrations(dialect)
+	if migrations == nil {
+		return -1
+	}
+
+	_, err = db.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, name VARCHAR(255) NOT NULL, applied TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL)")
+	if err != nil {
+		fmt.Println("error [create schema_migrations]:", err)
+		return -1
+	}
+
+	versions = appliedVersions(db)
+	if versions == nil {
+		return -1
+	}
+
+	for _, migration := range migrations {
+		if versions[migration.Version] {
+			continue
+		}
+
+		fmt.Printf("apply migration %04d %s\n", migration.Version, migration.Name)
+
+		if !applyMigration(db, migration) {
+			return -1
+		}
+
+		applied++
+	}
+
+	return applied
+}
+
+// read versions of already applied migrations.
+// return set of versions or nil on error
+func appliedVersions(db *sql.DB) (versions map[int]bool) {
+	var (
+		err error
+
+		row *sql.Rows
+	)
+
+	row, err = db.Query("SELECT version FROM schema_migrations")
+	if err != nil {
+		fmt.Println("error [read schema_migrations]:", err)
+		return nil
+	}
+
+	defer func() {
+		if err = row.Close(); err != nil {
+			fmt.Println("error [clear schema_migrations rows]:", err)
+		}
+	}()
+
+	versions = make(map[int]bool)
+
+	for row.Next() {
+		var (
+			version int
+		)
+
+		err = row.Scan(&version)
+		if err != nil {
+			fmt.Println("error [scan schema_migrations]:", err)
+			return nil
+		}
+
+		versions[version] = true
+	}
+
+	return versions
+}
+
+// execute migration and mark it as applied in single transaction.
+// return true on success
+func applyMigration(db *sql.DB, migration MigrationType) bool {
+	var (
+		err error
+
+		tx *sql.Tx
+	)
+
+	tx, err = db.Begin()
+	if err != nil {
+		fmt.Println("error [migration begin transaction]:", err)
+		return false
+	}
+
+	defer func() {
+		if err = tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			fmt.Println("error [migration rollback transaction]:", err)
+		}
+	}()
+
+	_, err = tx.Exec(migration.Query)
+	if err != nil {
+		fmt.Printf("error [apply migration %04d]: %v\n", migration.Version, err)
+		return false
+	}
+
+	_, err = tx.Exec("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+	if err != nil {
+		fmt.Println("error [mark migration applied]:", err)
+		return false
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		fmt.Println("error [migration commit transaction]:", err)
+		return false
+	}
+
+	return true
+}
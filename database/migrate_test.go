@@ -0,0 +1,128 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadMigrations(t *testing.T) {
+	for _, dialect := range []string{DialectPostgres, DialectSQLite} {
+		var (
+			migrations []MigrationType
+		)
+
+		migrations = ReadMigrations(dialect)
+		if len(migrations) == 0 {
+			t.Fatalf("%s: migrations not found", dialect)
+		}
+
+		for i, migration := range migrations {
+			if migration.Version != i+1 {
+				t.Errorf("%s: expected version %d, got %d (%s)", dialect, i+1, migration.Version, migration.Name)
+			}
+		}
+	}
+}
+
+func TestMigrateSQLite(t *testing.T) {
+	var (
+		err error
+
+		applied int
+		count   int
+
+		db *sql.DB
+	)
+
+	db = OpenSQLite(filepath.Join(t.TempDir(), "levels.db"))
+	if db == nil {
+		t.Fatal("can't open sqlite db")
+	}
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// migrations were applied on open, so nothing is pending
+	applied = Migrate(db, DialectSQLite)
+	if applied != 0 {
+		t.Errorf("expected no pending migrations, applied %d", applied)
+	}
+
+	err = db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != len(ReadMigrations(DialectSQLite)) {
+		t.Errorf("expected %d applied migrations, got %d", len(ReadMigrations(DialectSQLite)), count)
+	}
+
+	// unique index on games rejects the same game of the same creator
+	_, err = db.Exec("INSERT INTO creators (creator) VALUES ('creator')")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec("INSERT INTO games (creator_id, game) VALUES (1, 'game')")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec("INSERT INTO games (creator_id, game) VALUES (1, 'game')")
+	if err == nil {
+		t.Error("expected unique index violation for duplicated game")
+	}
+}
+
+func TestMigrateDumpSQLite(t *testing.T) {
+	var (
+		err error
+
+		applied  int
+		revision int
+		status   string
+
+		db *sql.DB
+	)
+
+	db, err = sql.Open("sqlite3", filepath.Join(t.TempDir(), "levels.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// db created from dump.sql has only data of levels and no schema_migrations
+	for _, query := range []string{
+		"CREATE TABLE creators (id INTEGER PRIMARY KEY AUTOINCREMENT, creator VARCHAR(255) NOT NULL)",
+		"CREATE TABLE games (id INTEGER PRIMARY KEY AUTOINCREMENT, creator_id BIGINT, game VARCHAR(255) NOT NULL)",
+		"CREATE TABLE levels (id INTEGER PRIMARY KEY AUTOINCREMENT, game_id BIGINT, level INTEGER NOT NULL, data JSON NOT NULL)",
+		"INSERT INTO creators (creator) VALUES ('creator')",
+		"INSERT INTO games (creator_id, game) VALUES (1, 'game')",
+		"INSERT INTO levels (game_id, level, data) VALUES (1, 1, '[[1,1,1],[1,4,1],[1,5,1],[1,1,1]]')",
+	} {
+		_, err = db.Exec(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	applied = Migrate(db, DialectSQLite)
+	if applied != len(ReadMigrations(DialectSQLite)) {
+		t.Fatalf("expected %d applied migrations, applied %d", len(ReadMigrations(DialectSQLite)), applied)
+	}
+
+	// existing level waits for analysis and its data becomes the first revision
+	err = db.QueryRow("SELECT l.msp_status, r.revision FROM levels l JOIN level_revisions r ON (r.level_id = l.id)").Scan(&status, &revision)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (status != "") || (revision != 1) {
+		t.Errorf("expected empty msp status and revision 1, got %q and %d", status, revision)
+	}
+}
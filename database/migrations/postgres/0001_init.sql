@@ -0,0 +1,26 @@
+-- tables of dump.sql as they are, plus unique indexes which dump.sql missed. existing deployments created from
+-- dump.sql keep their data because every object is created only if it doesn't exist
+
+CREATE TABLE IF NOT EXISTS public.creators (
+    id serial CONSTRAINT creators_pkey PRIMARY KEY,
+    creator character varying(255) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS creators_creator_uindex ON public.creators USING btree (creator);
+
+CREATE TABLE IF NOT EXISTS public.games (
+    id serial CONSTRAINT games_pkey PRIMARY KEY,
+    creator_id bigint CONSTRAINT games_creators_id_fk REFERENCES public.creators(id),
+    game character varying(255) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS games_creator_id_game_uindex ON public.games USING btree (creator_id, game);
+
+CREATE TABLE IF NOT EXISTS public.levels (
+    id serial CONSTRAINT levels_pkey PRIMARY KEY,
+    game_id bigint CONSTRAINT levels_games_id_fk REFERENCES public.games(id),
+    level integer NOT NULL,
+    data json NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS levels_game_id_level_uindex ON public.levels USING btree (game_id, level);
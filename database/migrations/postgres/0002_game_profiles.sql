@@ -0,0 +1,3 @@
+-- name of constraints profile of game, empty one means default constraints
+
+ALTER TABLE public.games ADD COLUMN IF NOT EXISTS profile character varying(255) DEFAULT '' NOT NULL;
@@ -0,0 +1,4 @@
+-- level data is stored in compact binary format instead of json, existing json data is kept as bytes of its text
+-- and is read as json until level is updated
+
+ALTER TABLE public.levels ALTER COLUMN data TYPE bytea USING convert_to(data::text, 'UTF8');
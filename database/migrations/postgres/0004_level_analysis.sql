@@ -0,0 +1,11 @@
+-- analysis of level is stored next to its data. existing levels get empty msp status, which means level isn't
+-- analyzed yet, service analyzes such levels on start and fills the columns
+
+ALTER TABLE public.levels ADD COLUMN IF NOT EXISTS msp_status character varying(32) DEFAULT '' NOT NULL;
+ALTER TABLE public.levels ADD COLUMN IF NOT EXISTS msp_length integer DEFAULT -1 NOT NULL;
+ALTER TABLE public.levels ADD COLUMN IF NOT EXISTS msp_path json DEFAULT '[]' NOT NULL;
+ALTER TABLE public.levels ADD COLUMN IF NOT EXISTS traps integer DEFAULT 0 NOT NULL;
+ALTER TABLE public.levels ADD COLUMN IF NOT EXISTS open_tiles integer DEFAULT 0 NOT NULL;
+ALTER TABLE public.levels ADD COLUMN IF NOT EXISTS reachable_area integer DEFAULT 0 NOT NULL;
+
+CREATE INDEX IF NOT EXISTS levels_msp_length_index ON public.levels USING btree (msp_length);
@@ -0,0 +1,15 @@
+-- every upload of level is kept as revision, actual data of existing levels becomes their first revision
+
+CREATE TABLE IF NOT EXISTS public.level_revisions (
+    id serial CONSTRAINT level_revisions_pkey PRIMARY KEY,
+    level_id bigint NOT NULL CONSTRAINT level_revisions_levels_id_fk REFERENCES public.levels(id),
+    revision integer NOT NULL,
+    data bytea NOT NULL,
+    created timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS level_revisions_level_id_revision_uindex ON public.level_revisions USING btree (level_id, revision);
+
+INSERT INTO public.level_revisions (level_id, revision, data)
+SELECT l.id, 1, l.data FROM public.levels l
+WHERE NOT EXISTS (SELECT 1 FROM public.level_revisions r WHERE (r.level_id = l.id));
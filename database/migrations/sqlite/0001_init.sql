@@ -0,0 +1,26 @@
+-- tables of dump.sql adapted to sqlite, plus unique indexes which dump.sql missed. every object is created only if
+-- it doesn't exist
+
+CREATE TABLE IF NOT EXISTS creators (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    creator VARCHAR(255) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS creators_creator_uindex ON creators (creator);
+
+CREATE TABLE IF NOT EXISTS games (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    creator_id BIGINT REFERENCES creators (id),
+    game VARCHAR(255) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS games_creator_id_game_uindex ON games (creator_id, game);
+
+CREATE TABLE IF NOT EXISTS levels (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    game_id BIGINT REFERENCES games (id),
+    level INTEGER NOT NULL,
+    data JSON NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS levels_game_id_level_uindex ON levels (game_id, level);
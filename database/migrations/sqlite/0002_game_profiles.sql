@@ -0,0 +1,3 @@
+-- name of constraints profile of game, empty one means default constraints
+
+ALTER TABLE games ADD COLUMN profile VARCHAR(255) DEFAULT '' NOT NULL;
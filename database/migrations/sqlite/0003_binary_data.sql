@@ -0,0 +1,4 @@
+-- level data is stored in compact binary format instead of json. sqlite keeps blob in column of any declared type,
+-- so existing json data stays as it is and is read as json until level is updated, only version is recorded
+
+SELECT 1;
@@ -0,0 +1,11 @@
+-- analysis of level is stored next to its data. existing levels get empty msp status, which means level isn't
+-- analyzed yet, service analyzes such levels on start and fills the columns
+
+ALTER TABLE levels ADD COLUMN msp_status VARCHAR(32) DEFAULT '' NOT NULL;
+ALTER TABLE levels ADD COLUMN msp_length INTEGER DEFAULT -1 NOT NULL;
+ALTER TABLE levels ADD COLUMN msp_path JSON DEFAULT '[]' NOT NULL;
+ALTER TABLE levels ADD COLUMN traps INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE levels ADD COLUMN open_tiles INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE levels ADD COLUMN reachable_area INTEGER DEFAULT 0 NOT NULL;
+
+CREATE INDEX IF NOT EXISTS levels_msp_length_index ON levels (msp_length);
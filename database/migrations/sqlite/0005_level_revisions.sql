@@ -0,0 +1,15 @@
+-- every upload of level is kept as revision, actual data of existing levels becomes their first revision
+
+CREATE TABLE IF NOT EXISTS level_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    level_id BIGINT NOT NULL REFERENCES levels (id),
+    revision INTEGER NOT NULL,
+    data JSON NOT NULL,
+    created TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS level_revisions_level_id_revision_uindex ON level_revisions (level_id, revision);
+
+INSERT INTO level_revisions (level_id, revision, data)
+SELECT l.id, 1, l.data FROM levels l
+WHERE NOT EXISTS (SELECT 1 FROM level_revisions r WHERE (r.level_id = l.id));
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// open (or create) sqlite db file with enabled foreign keys and apply pending schema migrations.
+// return db instance
+func OpenSQLite(path string) (db *sql.DB) {
+	var (
+		err error
+	)
+
+	db, err = sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000", path))
+	if err != nil {
+		fmt.Println("error [open sqlite db]:", err)
+		return nil
+	}
+
+	// sqlite allows single writer, so all queries share one connection
+	db.SetMaxOpenConns(1)
+
+	if Migrate(db, DialectSQLite) < 0 {
+		fmt.Println("error [migrate sqlite db]")
+
+		if err = db.Close(); err != nil {
+			fmt.Println("error [close sqlite db]:", err)
+		}
+
+		return nil
+	}
+
+	return db
+}
@@ -1,267 +0,0 @@
---
--- PostgreSQL database dump
---
-
--- Dumped from database version 13.4
--- Dumped by pg_dump version 13.4
-
-SET statement_timeout = 0;
-SET lock_timeout = 0;
-SET idle_in_transaction_session_timeout = 0;
-SET client_encoding = 'UTF8';
-SET standard_conforming_strings = on;
-SELECT pg_catalog.set_config('search_path', '', false);
-SET check_function_bodies = false;
-SET xmloption = content;
-SET client_min_messages = warning;
-SET row_security = off;
-
-DROP DATABASE greenjade;
---
--- Name: greenjade; Type: DATABASE; Schema: -; Owner: -
---
-
-CREATE DATABASE greenjade WITH TEMPLATE = template0 ENCODING = 'UTF8' LOCALE = 'C';
-
-
-\connect greenjade
-
-SET statement_timeout = 0;
-SET lock_timeout = 0;
-SET idle_in_transaction_session_timeout = 0;
-SET client_encoding = 'UTF8';
-SET standard_conforming_strings = on;
-SELECT pg_catalog.set_config('search_path', '', false);
-SET check_function_bodies = false;
-SET xmloption = content;
-SET client_min_messages = warning;
-SET row_security = off;
-
---
--- Name: public; Type: SCHEMA; Schema: -; Owner: -
---
-
-CREATE SCHEMA public;
-
-
---
--- Name: SCHEMA public; Type: COMMENT; Schema: -; Owner: -
---
-
-COMMENT ON SCHEMA public IS 'standard public schema';
-
-
-SET default_tablespace = '';
-
-SET default_table_access_method = heap;
-
---
--- Name: creators; Type: TABLE; Schema: public; Owner: -
---
-
-CREATE TABLE public.creators (
-    id integer NOT NULL,
-    creator character varying(255) NOT NULL
-);
-
-
---
--- Name: creators_id_seq; Type: SEQUENCE; Schema: public; Owner: -
---
-
-CREATE SEQUENCE public.creators_id_seq
-    AS integer
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
-
-
---
--- Name: creators_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
---
-
-ALTER SEQUENCE public.creators_id_seq OWNED BY public.creators.id;
-
-
---
--- Name: games; Type: TABLE; Schema: public; Owner: -
---
-
-CREATE TABLE public.games (
-    id integer NOT NULL,
-    creator_id bigint,
-    game character varying(255) NOT NULL
-);
-
-
---
--- Name: games_id_seq; Type: SEQUENCE; Schema: public; Owner: -
---
-
-CREATE SEQUENCE public.games_id_seq
-    AS integer
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
-
-
---
--- Name: games_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
---
-
-ALTER SEQUENCE public.games_id_seq OWNED BY public.games.id;
-
-
---
--- Name: levels; Type: TABLE; Schema: public; Owner: -
---
-
-CREATE TABLE public.levels (
-    id integer NOT NULL,
-    game_id bigint,
-    level integer NOT NULL,
-    data json NOT NULL
-);
-
-
---
--- Name: levels_id_seq; Type: SEQUENCE; Schema: public; Owner: -
---
-
-CREATE SEQUENCE public.levels_id_seq
-    AS integer
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
-
-
---
--- Name: levels_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
---
-
-ALTER SEQUENCE public.levels_id_seq OWNED BY public.levels.id;
-
-
---
--- Name: creators id; Type: DEFAULT; Schema: public; Owner: -
---
-
-ALTER TABLE ONLY public.creators ALTER COLUMN id SET DEFAULT nextval('public.creators_id_seq'::regclass);
-
-
---
--- Name: games id; Type: DEFAULT; Schema: public; Owner: -
---
-
-ALTER TABLE ONLY public.games ALTER COLUMN id SET DEFAULT nextval('public.games_id_seq'::regclass);
-
-
---
--- Name: levels id; Type: DEFAULT; Schema: public; Owner: -
---
-
-ALTER TABLE ONLY public.levels ALTER COLUMN id SET DEFAULT nextval('public.levels_id_seq'::regclass);
-
-
---
--- Data for Name: creators; Type: TABLE DATA; Schema: public; Owner: -
---
-
-COPY public.creators (id, creator) FROM stdin;
-\.
-
-
---
--- Data for Name: games; Type: TABLE DATA; Schema: public; Owner: -
---
-
-COPY public.games (id, creator_id, game) FROM stdin;
-\.
-
-
---
--- Data for Name: levels; Type: TABLE DATA; Schema: public; Owner: -
---
-
-COPY public.levels (id, game_id, level, data) FROM stdin;
-\.
-
-
---
--- Name: creators_id_seq; Type: SEQUENCE SET; Schema: public; Owner: -
---
-
-SELECT pg_catalog.setval('public.creators_id_seq', 1, false);
-
-
---
--- Name: games_id_seq; Type: SEQUENCE SET; Schema: public; Owner: -
---
-
-SELECT pg_catalog.setval('public.games_id_seq', 1, false);
-
-
---
--- Name: levels_id_seq; Type: SEQUENCE SET; Schema: public; Owner: -
---
-
-SELECT pg_catalog.setval('public.levels_id_seq', 1, false);
-
-
---
--- Name: creators creators_pkey; Type: CONSTRAINT; Schema: public; Owner: -
---
-
-ALTER TABLE ONLY public.creators
-    ADD CONSTRAINT creators_pkey PRIMARY KEY (id);
-
-
---
--- Name: games games_pkey; Type: CONSTRAINT; Schema: public; Owner: -
---
-
-ALTER TABLE ONLY public.games
-    ADD CONSTRAINT games_pkey PRIMARY KEY (id);
-
-
---
--- Name: levels levels_pkey; Type: CONSTRAINT; Schema: public; Owner: -
---
-
-ALTER TABLE ONLY public.levels
-    ADD CONSTRAINT levels_pkey PRIMARY KEY (id);
-
-
---
--- Name: creators_creator_uindex; Type: INDEX; Schema: public; Owner: -
---
-
-CREATE UNIQUE INDEX creators_creator_uindex ON public.creators USING btree (creator);
-
-
---
--- Name: games games_creators_id_fk; Type: FK CONSTRAINT; Schema: public; Owner: -
---
-
-ALTER TABLE ONLY public.games
-    ADD CONSTRAINT games_creators_id_fk FOREIGN KEY (creator_id) REFERENCES public.creators(id);
-
-
---
--- Name: levels levels_games_id_fk; Type: FK CONSTRAINT; Schema: public; Owner: -
---
-
-ALTER TABLE ONLY public.levels
-    ADD CONSTRAINT levels_games_id_fk FOREIGN KEY (game_id) REFERENCES public.games(id);
-
-
---
--- PostgreSQL database dump complete
---
-
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"greenjade/archive"
+	"greenjade/config"
+	"greenjade/model"
+	"net/http"
+	"time"
+)
+
+// move whole game between environments:
+// GET /games/archive?creator=...&game=... - download game with all its levels as zip archive;
+// POST /games/archive?conflict=skip|overwrite|rename with archive as body - import game in single transaction.
+// without conflict option import of existing game gets 409.
+func (server *ServerType) HandlerArchive(w http.ResponseWriter, r *http.Request) {
+	fmt.Println()
+
+	switch r.Method {
+	case http.MethodGet:
+		server.exportArchive(w, r)
+	case http.MethodPost:
+		server.importArchive(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "I'm ready to GET and POST only")
+	}
+}
+
+// write game archive as attachment
+func (server *ServerType) exportArchive(w http.ResponseWriter, r *http.Request) {
+	var (
+		err, status error
+
+		game   model.GameArchiveType
+		buffer bytes.Buffer
+	)
+
+	if (r.URL.Query().Get("creator") == "") || (r.URL.Query().Get("game") == "") {
+		writeError(w, http.StatusBadRequest, "bad_request", "creator and game are required")
+		return
+	}
+
+	game, status = archive.Export(server.Storage, r.URL.Query().Get("creator"), r.URL.Query().Get("game"))
+	if status != nil {
+		writeModelResult(w, status, http.StatusOK, nil)
+		return
+	}
+
+	fmt.Println("export game:", game.Game, "creator:", game.Creator, "levels:", len(game.Levels))
+
+	// archive is built in memory, so error doesn't break response which is already started
+	err = archive.Write(&buffer, game, time.Now())
+	if err != nil {
+		fmt.Println("[error] write game archive:", err)
+		writeError(w, http.StatusInternalServerError, "error", "can't write game archive")
+		return
+	}
+
+	w.Header().Set("Content-Type", archive.MediaType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", game.Game+archive.Extension))
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(buffer.Bytes())
+	if err != nil {
+		fmt.Println("[error] write game archive response:", err)
+	}
+}
+
+// validate every level of archive and import game, response contains name of imported game and ids of its levels.
+// created or renamed game gets 201, overwritten or skipped one gets 200.
+func (server *ServerType) importArchive(w http.ResponseWriter, r *http.Request) {
+	var (
+		err, status error
+
+		body        []byte
+		code        int
+		game        model.GameArchiveType
+		conflict    string
+		result      model.ImportResultType
+		constraints config.ConstraintsType
+	)
+
+	conflict = r.URL.Query().Get("conflict")
+	if !model.IsConflictOption(conflict) {
+		writeError(w, http.StatusBadRequest, "bad_request", fmt.Sprintf("conflict must be %s, %s or %s", model.ConflictSkip, model.ConflictOverwrite, model.ConflictRename))
+		return
+	}
+
+	// archive is read whole into memory, so its size is limited as size of any other body
+	body, code, err = server.readBody(w, r)
+	if err != nil {
+		fmt.Println("[error] read game archive:", err)
+		writeError(w, code, decodeStatus(code), fmt.Sprintf("can't read request body: %s", err.Error()))
+		return
+	}
+
+	constraints = server.Constraints()
+
+	game, err = archive.Read(body, archive.LimitsType{Size: int64(server.Cfg.Server.MaxBody) * archive.UnpackRatio, Side: constraints.MaxSide()})
+	if err != nil {
+		fmt.Println("[error] read game archive:", err)
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	// archive may come from environment with another profiles, unknown profile is error rather than silent default
+	if !constraints.HasProfile(game.Profile) {
+		writeError(w, http.StatusUnprocessableEntity, "unknown_profile", fmt.Sprintf("constraints profile %q is not found in config", game.Profile))
+		return
+	}
+
+	status = game.Validate(constraints)
+	if status != nil {
+		fmt.Println("[error] game archive is not valid:", status.Error())
+		writeValidationError(w, status)
+		return
+	}
+
+	fmt.Println("import game:", game.Game, "creator:", game.Creator, "levels:", len(game.Levels), "conflict:", conflict)
+
+	result, status = server.Storage.ImportGame(&game, conflict, constraints)
+	if (status == nil) && ((result.Status == model.ImportCreated) || (result.Status == model.ImportRenamed)) {
+		writeModelResult(w, status, http.StatusCreated, result)
+		return
+	}
+
+	if status == model.ErrConflict {
+		writeError(w, http.StatusConflict, "conflict", fmt.Sprintf("game %q of creator %q already exists, choose conflict option", game.Game, game.Creator))
+		return
+	}
+
+	writeModelResult(w, status, http.StatusOK, result)
+}
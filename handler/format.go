@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"greenjade/codec"
+	"greenjade/model"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+const (
+	MediaTypeJSON = "application/json" // default media type of level with creator, game and number
+	FormatJSON    = "json"             // default output format
+)
+
+// read whole request body, body which is bigger than server.max_body of config is rejected.
+// return body, http code and error if body can't be read
+func (server *ServerType) readBody(w http.ResponseWriter, r *http.Request) (body []byte, code int, err error) {
+	var (
+		limit int64
+	)
+
+	limit = int64(server.Cfg.Server.MaxBody)
+
+	body, err = ioutil.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if (err != nil) && (int64(len(body)) >= limit) {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("request body is bigger than %d bytes", limit)
+	}
+
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	return body, http.StatusOK, nil
+}
+
+// read json request body into object, body which is bigger than server.max_body of config is rejected.
+// return http code and error if body can't be read or decoded
+func (server *ServerType) decodeJSON(w http.ResponseWriter, r *http.Request, obj interface{}) (code int, err error) {
+	var (
+		body []byte
+	)
+
+	body, code, err = server.readBody(w, r)
+	if err != nil {
+		return code, err
+	}
+
+	err = json.Unmarshal(body, obj)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return http.StatusOK, nil
+}
+
+// decode level from request body by Content-Type. json body contains level with creator, game and number, other
+// formats contain level data and maybe creator, game and number (e.g. map properties), query overrides them.
+// return http code and error if body can't be decoded
+func (server *ServerType) decodeLevel(w http.ResponseWriter, r *http.Request, level *model.LevelType) (code int, err error) {
+	var (
+		levelCodec codec.CodecType
+		metaCodec  codec.MetaCodecType
+		meta       codec.MetaType
+		body       []byte
+		ok         bool
+	)
+
+	// json is default format, it keeps backward compatibility with clients which don't set Content-Type
+	if (r.Header.Get("Content-Type") == "") || isJSON(r.Header.Get("Content-Type")) {
+		return server.decodeJSON(w, r, level)
+	}
+
+	levelCodec, err = codec.ByMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return http.StatusUnsupportedMediaType, fmt.Errorf("%w %q, expected %s or one of %v", err, r.Header.Get("Content-Type"), MediaTypeJSON, codec.Names())
+	}
+
+	body, code, err = server.readBody(w, r)
+	if err != nil {
+		return code, err
+	}
+
+	// size of level is checked while it's decoded, with constraints which are actual now
+	levelCodec = codec.WithMaxSide(levelCodec, server.Constraints().MaxSide())
+
+	metaCodec, ok = levelCodec.(codec.MetaCodecType)
+	if ok {
+		meta, level.Data, err = metaCodec.DecodeLevel(body)
+	} else {
+		level.Data, err = levelCodec.Decode(body)
+	}
+
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	level.Creator = meta.Creator
+	level.Game = meta.Game
+	level.Level = meta.Level
+
+	if r.URL.Query().Get("creator") != "" {
+		level.Creator = r.URL.Query().Get("creator")
+	}
+
+	if r.URL.Query().Get("game") != "" {
+		level.Game = r.URL.Query().Get("game")
+	}
+
+	if r.URL.Query().Get("level") != "" {
+		level.Level, err = strconv.ParseInt(r.URL.Query().Get("level"), 10, 64)
+		if err != nil {
+			return http.StatusBadRequest, errors.New("level must be integer")
+		}
+	}
+
+	return http.StatusOK, nil
+}
+
+// return short status of decoding error for http code
+func decodeStatus(code int) string {
+	switch code {
+	case http.StatusUnsupportedMediaType:
+		return "unsupported_media_type"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	}
+
+	return "bad_request"
+}
+
+// return true if Content-Type is json one, parameters like charset are ignored
+func isJSON(contentType string) bool {
+	var (
+		mediaType string
+	)
+
+	mediaType, _, _ = mime.ParseMediaType(contentType)
+
+	return mediaType == MediaTypeJSON
+}
+
+// write level in requested output format: json with all level fields (default), or level data in format of codec,
+// with creator, game and number if format keeps them.
+func writeLevelFormat(w http.ResponseWriter, format string, level model.LevelType) {
+	var (
+		err error
+
+		levelCodec codec.CodecType
+		metaCodec  codec.MetaCodecType
+		output     []byte
+		ok         bool
+	)
+
+	if (format == "") || (format == FormatJSON) {
+		writeJSON(w, http.StatusOK, level)
+		return
+	}
+
+	levelCodec, err = codec.ByName(format)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", fmt.Sprintf("unknown format %q, expected %s or one of %v", format, FormatJSON, codec.Names()))
+		return
+	}
+
+	metaCodec, ok = levelCodec.(codec.MetaCodecType)
+	if ok {
+		output, err = metaCodec.EncodeLevel(codec.MetaType{Creator: level.Creator, Game: level.Game, Level: level.Level}, level.Data)
+	} else {
+		output, err = levelCodec.Encode(level.Data)
+	}
+
+	if err != nil {
+		fmt.Println("[error] encode level:", err)
+		writeError(w, http.StatusInternalServerError, "error", fmt.Sprintf("can't encode level: %s", err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", levelCodec.MediaType())
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(output)
+	if err != nil {
+		fmt.Println("[error] write level response:", err)
+	}
+}
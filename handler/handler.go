@@ -2,19 +2,58 @@
 package handler
 
 import (
-	"database/sql"
-	"encoding/json"
 	"fmt"
 	"greenjade/analyze"
 	"greenjade/config"
 	"greenjade/model"
 	"net/http"
+	"sync/atomic"
 )
 
-// base server structure with global objects such as db and config
+// base server structure with global objects such as storage and config. constraints can be replaced while server
+// is running, so handlers read them by Constraints method
 type ServerType struct {
-	DB  *sql.DB
-	Cfg *config.ConfType
+	Storage model.RepositoryType
+	Cfg     *config.ConfType
+
+	constraints atomic.Value
+}
+
+// constraints which are actual at the moment, from last reload or from config.
+// return copy of constraints, so request works with the same values from start to end
+func (server *ServerType) Constraints() config.ConstraintsType {
+	var (
+		constraints *config.ConstraintsType
+		ok          bool
+	)
+
+	constraints, ok = server.constraints.Load().(*config.ConstraintsType)
+	if !ok {
+		return server.Cfg.Constraints
+	}
+
+	return *constraints
+}
+
+// atomically replace constraints, requests in progress keep previous ones
+func (server *ServerType) SetConstraints(constraints config.ConstraintsType) {
+	server.constraints.Store(&constraints)
+}
+
+// register all handlers of the service.
+// return router for http server
+func (server *ServerType) Routes() (mux *http.ServeMux) {
+	mux = http.NewServeMux()
+
+	mux.HandleFunc("/", server.Handler)
+	mux.HandleFunc("/msp", server.HandlerMSP)
+	mux.HandleFunc("/levels", server.HandlerLevels)
+	mux.HandleFunc("/levels/", server.HandlerLevels)
+	mux.HandleFunc("/games", server.HandlerGames)
+	mux.HandleFunc("/games/archive", server.HandlerArchive)
+	mux.HandleFunc("/creators", server.HandlerCreators)
+
+	return mux
 }
 
 // filtering request type, decoding request body, validate input json and store json data in db.
@@ -24,46 +63,41 @@ func (server *ServerType) Handler(w http.ResponseWriter, r *http.Request) {
 	var (
 		err, status error
 
-		decoder *json.Decoder
-		level   model.LevelType
+		level model.LevelType
+		code  int
 
-		resource int64
+		resource    int64
+		constraints config.ConstraintsType
 	)
 
 	fmt.Println()
 
 	// we wait only POST request
-	if r.Method == http.MethodGet {
-		w.WriteHeader(http.StatusOK)
-
-		_, err = w.Write([]byte("I'm ready to POST only"))
-		if err != nil {
-			fmt.Println("[error] processing wrong request type:", err)
-			http.Error(w, "error", http.StatusInternalServerError)
-			return
-		}
-
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "I'm ready to POST only")
 		return
 	}
 
-	// convert request body to level structure
-	decoder = json.NewDecoder(r.Body)
-	err = decoder.Decode(&level)
+	// convert request body to level structure by its Content-Type, malformed body is client's error
+	code, err = server.decodeLevel(w, r, &level)
 	if err != nil {
 		fmt.Println("[error] decode request params:", err)
-		http.Error(w, "error", http.StatusInternalServerError)
+		writeError(w, code, decodeStatus(code), fmt.Sprintf("can't decode request body: %s", err.Error()))
 
 		return
 	}
 
-	// pass to level's instance db connection
-	level.DB = server.DB
+	// existing game may have its own constraints profile
+	if !server.loadProfile(w, &level) {
+		return
+	}
 
-	// before store we need do some validation
-	status = level.Validate(server.Cfg.Constraints)
+	// before store we need do some validation, the same constraints are used for storing
+	constraints = server.Constraints()
+	status = level.Validate(constraints)
 	if status != nil {
 		fmt.Println("[error] level is not valid:", status.Error())
-		http.Error(w, status.Error(), http.StatusUnprocessableEntity)
+		writeValidationError(w, status)
 
 		return
 	}
@@ -74,10 +108,10 @@ func (server *ServerType) Handler(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("data:", level.Data)
 
 	// store level data only if it's correct
-	resource = level.Store()
+	resource = server.Storage.StoreLevel(&level, constraints)
 	if resource <= 0 {
 		fmt.Println("[error] storing level data failed")
-		http.Error(w, "error", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, "error", "storing level data failed")
 
 		return
 	}
@@ -90,45 +124,36 @@ func (server *ServerType) Handler(w http.ResponseWriter, r *http.Request) {
 	_, err = w.Write([]byte(fmt.Sprintf("%d", resource)))
 	if err != nil {
 		fmt.Println("[error] build success response:", err)
-		http.Error(w, "error", http.StatusInternalServerError)
 		return
 	}
 }
 
 // filtering request type, decoding request body, calculate minimal survivable path (MSP).
-// build response with MSP value.
+// build response with MSP value and path itself.
 func (server *ServerType) HandlerMSP(w http.ResponseWriter, r *http.Request) {
 	var (
-		err error
+		err, status error
 
-		decoder *json.Decoder
-		level   model.LevelType
+		level       model.LevelType
+		code        int
+		constraints config.ConstraintsType
 
-		mspLength int
+		msp analyze.ResultType
 	)
 
 	fmt.Println()
 
 	// we wait only POST request
-	if r.Method == http.MethodGet {
-		w.WriteHeader(http.StatusOK)
-
-		_, err = w.Write([]byte("I'm ready to POST only"))
-		if err != nil {
-			fmt.Println("[error] processing wrong request type:", err)
-			http.Error(w, "error", http.StatusInternalServerError)
-			return
-		}
-
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "I'm ready to POST only")
 		return
 	}
 
-	// convert request body to level structure
-	decoder = json.NewDecoder(r.Body)
-	err = decoder.Decode(&level)
+	// convert request body to level structure by its Content-Type, malformed body is client's error
+	code, err = server.decodeLevel(w, r, &level)
 	if err != nil {
 		fmt.Println("[error] decode request params:", err)
-		http.Error(w, "error", http.StatusInternalServerError)
+		writeError(w, code, decodeStatus(code), fmt.Sprintf("can't decode request body: %s", err.Error()))
 
 		return
 	}
@@ -137,17 +162,57 @@ func (server *ServerType) HandlerMSP(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("game:", level.Game)
 	fmt.Println("level:", level.Level)
 
-	// calculating minimal survivable path
-	mspLength = analyze.MinSurvivablePathLen(level.Data)
-	fmt.Println("msp length:", mspLength)
+	// calculating minimal survivable path with constraints of game's profile
+	if !server.loadProfile(w, &level) {
+		return
+	}
 
-	// prepare response
-	w.WriteHeader(http.StatusCreated)
+	constraints = level.ProfileConstraints(server.Constraints())
+
+	// analysis cost grows with size of level, so level must fit dimensions before it's analyzed
+	status = level.ValidateShape(constraints)
+	if status != nil {
+		fmt.Println("[error] level is not valid:", status.Error())
+		writeValidationError(w, status)
+
+		return
+	}
+
+	msp, status = analyze.Analyze(level.Data, constraints)
+	if status != nil {
+		fmt.Println("[error] level has no msp:", status.Error())
+		writeAnalyzeError(w, status)
 
-	_, err = w.Write([]byte(fmt.Sprintf("%d", mspLength)))
-	if err != nil {
-		fmt.Println("[error] build success response:", err)
-		http.Error(w, "error", http.StatusInternalServerError)
 		return
 	}
+
+	fmt.Println("msp length:", msp.Length)
+	fmt.Println("msp path:", msp.Path)
+
+	// prepare response
+	writeJSON(w, http.StatusCreated, msp)
+}
+
+// find level's game and take its constraints profile, new game has default constraints.
+// in case of storage error response with error code. return true if level is ready to validate
+func (server *ServerType) loadProfile(w http.ResponseWriter, level *model.LevelType) bool {
+	var (
+		status error
+
+		game model.GameType
+	)
+
+	game = model.GameType{Creator: level.Creator, Game: level.Game}
+
+	status = server.Storage.LoadGame(&game)
+	if (status != nil) && (status != model.ErrNotFound) {
+		fmt.Println("[error] load game profile:", status.Error())
+		writeError(w, http.StatusInternalServerError, "error", "can't load game")
+
+		return false
+	}
+
+	level.Profile = game.Profile
+
+	return true
 }
@@ -0,0 +1,1083 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"greenjade/archive"
+	"greenjade/bitmap"
+	"greenjade/codec"
+	"greenjade/config"
+	"greenjade/database"
+	"greenjade/model"
+	"greenjade/tiled"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func buildServer(t *testing.T, storage model.RepositoryType) (server *httptest.Server) {
+	var (
+		err error
+
+		cfg *config.ConfType
+	)
+
+	cfg, err = config.BuildConfig("../")
+	if err != nil {
+		t.Error("[error] can't build config:", err)
+		t.FailNow()
+	}
+
+	server = httptest.NewServer((&ServerType{Storage: storage, Cfg: cfg}).Routes())
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func sendRequest(t *testing.T, method, url, body string) (code int, response string) {
+	return sendContent(t, method, url, "", body)
+}
+
+// send request with specific Content-Type, empty one isn't set.
+// return http code and response body
+func sendContent(t *testing.T, method, url, contentType, body string) (code int, response string) {
+	var (
+		err error
+
+		request *http.Request
+		reply   *http.Response
+		data    []byte
+	)
+
+	request, err = http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+
+	reply, err = http.DefaultClient.Do(request)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	defer func() {
+		if err = reply.Body.Close(); err != nil {
+			t.Error(err.Error())
+		}
+	}()
+
+	data, err = ioutil.ReadAll(reply.Body)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	return reply.StatusCode, string(data)
+}
+
+func readFile(t *testing.T, path string) string {
+	var (
+		err  error
+		data []byte
+	)
+
+	data, err = ioutil.ReadFile(path)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	return string(data)
+}
+
+func TestHandlerStoreAndReadMemory(t *testing.T) {
+	testStoreAndRead(t, model.NewMemoryStorage())
+}
+
+func TestHandlerStoreAndReadSQLite(t *testing.T) {
+	testStoreAndRead(t, buildSQLite(t))
+}
+
+// open sqlite db in temporary directory, db is closed after test.
+// return sql repository
+func buildSQLite(t *testing.T) (storage *model.SQLType) {
+	var (
+		db *sql.DB
+	)
+
+	db = database.OpenSQLite(filepath.Join(t.TempDir(), "levels.db"))
+	if db == nil {
+		t.Error("[error] can't open sqlite db")
+		t.FailNow()
+	}
+
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Error(err.Error())
+		}
+	})
+
+	return &model.SQLType{DB: db}
+}
+
+func testStoreAndRead(t *testing.T, storage model.RepositoryType) {
+	var (
+		err error
+
+		server *httptest.Server
+		code   int
+		body   string
+
+		level     model.LevelType
+		revisions []model.RevisionType
+	)
+
+	server = buildServer(t, storage)
+
+	code, body = sendRequest(t, http.MethodPost, server.URL, readFile(t, "../testdata/data_all_ok_2_msp_12.json"))
+	if (code != http.StatusCreated) || (body != "1") {
+		t.Errorf("unexpected store response %d: %s", code, body)
+		t.FailNow()
+	}
+
+	// the same level is stored again as new revision
+	code, body = sendRequest(t, http.MethodPost, server.URL, readFile(t, "../testdata/data_all_ok_2_msp_16.json"))
+	if (code != http.StatusCreated) || (body != "1") {
+		t.Errorf("unexpected store response %d: %s", code, body)
+		t.FailNow()
+	}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels?creator=all%20ok%202&game=labyrinth&level=1", "")
+	if code != http.StatusOK {
+		t.Errorf("unexpected read response %d: %s", code, body)
+		t.FailNow()
+	}
+
+	err = json.Unmarshal([]byte(body), &level)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	if level.Analysis.Length != 16 {
+		t.Errorf("expected stored msp length 16, got %d", level.Analysis.Length)
+	}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels/1/revisions", "")
+	if code != http.StatusOK {
+		t.Errorf("unexpected revisions response %d: %s", code, body)
+		t.FailNow()
+	}
+
+	err = json.Unmarshal([]byte(body), &revisions)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	if len(revisions) != 2 {
+		t.Errorf("expected 2 revisions, got %d", len(revisions))
+	}
+
+	code, _ = sendRequest(t, http.MethodDelete, server.URL+"/creators?creator=all%20ok%202", "")
+	if code != http.StatusNoContent {
+		t.Errorf("unexpected delete response %d", code)
+	}
+
+	code, _ = sendRequest(t, http.MethodGet, server.URL+"/levels/1", "")
+	if code != http.StatusNotFound {
+		t.Errorf("expected deleted level to be not found, got %d", code)
+	}
+}
+
+func TestHandlerRevisionsMemory(t *testing.T) {
+	testRevisions(t, model.NewMemoryStorage())
+}
+
+func TestHandlerRevisionsSQLite(t *testing.T) {
+	testRevisions(t, buildSQLite(t))
+}
+
+func testRevisions(t *testing.T, storage model.RepositoryType) {
+	var (
+		err error
+
+		server *httptest.Server
+		code   int
+		body   string
+
+		first, second model.LevelType
+		level         model.LevelType
+		revision      model.RevisionType
+		revisions     []model.RevisionType
+		diff          DiffResponseType
+	)
+
+	server = buildServer(t, storage)
+
+	for _, file := range []struct {
+		name  string
+		level *model.LevelType
+	}{{"../testdata/data_all_ok_2_msp_12.json", &first}, {"../testdata/data_all_ok_2_msp_16.json", &second}} {
+		err = json.Unmarshal([]byte(readFile(t, file.name)), file.level)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		code, body = sendRequest(t, http.MethodPost, server.URL, readFile(t, file.name))
+		if (code != http.StatusCreated) || (body != "1") {
+			t.Fatalf("unexpected store response %d: %s", code, body)
+		}
+	}
+
+	// single revision keeps data of its time
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels/1/revisions/1", "")
+	if code != http.StatusOK {
+		t.Fatalf("unexpected revision response %d: %s", code, body)
+	}
+
+	err = json.Unmarshal([]byte(body), &revision)
+	if (err != nil) || (revision.Revision != 1) || !reflect.DeepEqual(revision.Data, first.Data) {
+		t.Errorf("expected the first data in revision 1, got %v %+v", err, revision)
+	}
+
+	code, _ = sendRequest(t, http.MethodGet, server.URL+"/levels/1/revisions/9", "")
+	if code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown revision, got %d", code)
+	}
+
+	code, _ = sendRequest(t, http.MethodGet, server.URL+"/levels/1/revisions/last", "")
+	if code != http.StatusBadRequest {
+		t.Errorf("expected 400 for revision which isn't number, got %d", code)
+	}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels/1/diff?from=1&to=2", "")
+	if code != http.StatusOK {
+		t.Fatalf("unexpected diff response %d: %s", code, body)
+	}
+
+	err = json.Unmarshal([]byte(body), &diff)
+	if (err != nil) || (diff.From != 1) || (diff.To != 2) || (len(diff.Cells) == 0) || !reflect.DeepEqual(diff.Cells, model.DiffData(first.Data, second.Data)) {
+		t.Errorf("unexpected diff %v %+v", err, diff)
+	}
+
+	code, _ = sendRequest(t, http.MethodGet, server.URL+"/levels/1/diff?from=1", "")
+	if code != http.StatusBadRequest {
+		t.Errorf("expected 400 for diff without second revision, got %d", code)
+	}
+
+	code, _ = sendRequest(t, http.MethodGet, server.URL+"/levels/1/diff?from=1&to=9", "")
+	if code != http.StatusNotFound {
+		t.Errorf("expected 404 for diff with unknown revision, got %d", code)
+	}
+
+	// rollback doesn't rewrite history, old data becomes new revision
+	code, body = sendRequest(t, http.MethodPost, server.URL+"/levels/1/rollback?revision=1", "")
+	if code != http.StatusOK {
+		t.Fatalf("unexpected rollback response %d: %s", code, body)
+	}
+
+	err = json.Unmarshal([]byte(body), &level)
+	if (err != nil) || (level.Id != 1) || !reflect.DeepEqual(level.Data, first.Data) {
+		t.Errorf("expected the first data after rollback, got %v %+v", err, level)
+	}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels/1/revisions", "")
+	if code != http.StatusOK {
+		t.Fatalf("unexpected revisions response %d: %s", code, body)
+	}
+
+	err = json.Unmarshal([]byte(body), &revisions)
+	if (err != nil) || (len(revisions) != 3) {
+		t.Errorf("expected 3 revisions after rollback, got %v %s", err, body)
+	}
+
+	revision = model.RevisionType{}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels/1/revisions/3", "")
+	err = json.Unmarshal([]byte(body), &revision)
+	if (code != http.StatusOK) || (err != nil) || !reflect.DeepEqual(revision.Data, first.Data) {
+		t.Errorf("expected the first data in revision 3, got %d %v %s", code, err, body)
+	}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels/1/diff?from=1&to=3", "")
+	if (code != http.StatusOK) || !strings.Contains(body, `"cells":[]`) {
+		t.Errorf("expected no difference between revision 1 and its rollback, got %d: %s", code, body)
+	}
+
+	code, _ = sendRequest(t, http.MethodPost, server.URL+"/levels/1/rollback?revision=9", "")
+	if code != http.StatusNotFound {
+		t.Errorf("expected 404 for rollback to unknown revision, got %d", code)
+	}
+
+	code, _ = sendRequest(t, http.MethodPost, server.URL+"/levels/1/rollback", "")
+	if code != http.StatusBadRequest {
+		t.Errorf("expected 400 for rollback without revision, got %d", code)
+	}
+}
+
+func TestHandlerChangesMemory(t *testing.T) {
+	testChanges(t, model.NewMemoryStorage())
+}
+
+func TestHandlerChangesSQLite(t *testing.T) {
+	testChanges(t, buildSQLite(t))
+}
+
+func testChanges(t *testing.T, storage model.RepositoryType) {
+	var (
+		err error
+
+		server *httptest.Server
+		code   int
+		body   string
+
+		level model.LevelType
+	)
+
+	server = buildServer(t, storage)
+
+	for _, number := range []string{"1", "2"} {
+		code, body = sendRequest(t, http.MethodPost, server.URL, strings.Replace(readFile(t, "../testdata/data_all_ok_2_msp_12.json"), `"level": 1`, `"level": `+number, 1))
+		if (code != http.StatusCreated) || (body != number) {
+			t.Fatalf("unexpected store response %d: %s", code, body)
+		}
+	}
+
+	// renumber level
+	code, body = sendRequest(t, http.MethodPatch, server.URL+"/levels/1", `{"level": 3}`)
+	if code != http.StatusOK {
+		t.Fatalf("unexpected renumber response %d: %s", code, body)
+	}
+
+	err = json.Unmarshal([]byte(body), &level)
+	if (err != nil) || (level.Id != 1) || (level.Level != 3) {
+		t.Errorf("expected level 3 after renumber, got %v %+v", err, level)
+	}
+
+	code, _ = sendRequest(t, http.MethodPatch, server.URL+"/levels/1", `{"level": 2}`)
+	if code != http.StatusConflict {
+		t.Errorf("expected 409 for number of another level, got %d", code)
+	}
+
+	code, _ = sendRequest(t, http.MethodPatch, server.URL+"/levels/9", `{"level": 4}`)
+	if code != http.StatusNotFound {
+		t.Errorf("expected 404 for renumber of unknown level, got %d", code)
+	}
+
+	code, _ = sendRequest(t, http.MethodPatch, server.URL+"/levels/1", `{"level":`)
+	if code != http.StatusBadRequest {
+		t.Errorf("expected 400 for broken patch, got %d", code)
+	}
+
+	// rename game, levels follow it
+	code, body = sendRequest(t, http.MethodPatch, server.URL+"/games?creator=all%20ok%202&game=labyrinth", `{"game": "maze"}`)
+	if code != http.StatusOK {
+		t.Fatalf("unexpected rename game response %d: %s", code, body)
+	}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels?creator=all%20ok%202&game=maze&level=3", "")
+	if (code != http.StatusOK) || !strings.Contains(body, `"Game":"maze"`) {
+		t.Errorf("expected level in renamed game, got %d: %s", code, body)
+	}
+
+	code, _ = sendRequest(t, http.MethodGet, server.URL+"/levels?creator=all%20ok%202&game=labyrinth&level=3", "")
+	if code != http.StatusNotFound {
+		t.Errorf("expected no level in old game, got %d", code)
+	}
+
+	code, _ = sendRequest(t, http.MethodPatch, server.URL+"/games?creator=all%20ok%202&game=labyrinth", `{"game": "cave"}`)
+	if code != http.StatusNotFound {
+		t.Errorf("expected 404 for rename of unknown game, got %d", code)
+	}
+
+	code, _ = sendRequest(t, http.MethodPatch, server.URL+"/games?creator=all%20ok%202&game=maze", `{}`)
+	if code != http.StatusBadRequest {
+		t.Errorf("expected 400 for patch without changes, got %d", code)
+	}
+
+	// rename creator to its own name changes nothing
+	code, body = sendRequest(t, http.MethodPatch, server.URL+"/creators?creator=all%20ok%202", `{"creator": "all ok 2"}`)
+	if code != http.StatusOK {
+		t.Errorf("expected 200 for rename of creator to the same name, got %d: %s", code, body)
+	}
+
+	code, _ = sendRequest(t, http.MethodPatch, server.URL+"/creators?creator=nobody", `{"creator": "nobody"}`)
+	if code != http.StatusNotFound {
+		t.Errorf("expected 404 for rename of unknown creator to the same name, got %d", code)
+	}
+
+	// delete level
+	code, _ = sendRequest(t, http.MethodDelete, server.URL+"/levels/1", "")
+	if code != http.StatusNoContent {
+		t.Errorf("unexpected delete level response %d", code)
+	}
+
+	code, _ = sendRequest(t, http.MethodGet, server.URL+"/levels/1", "")
+	if code != http.StatusNotFound {
+		t.Errorf("expected deleted level to be not found, got %d", code)
+	}
+
+	code, _ = sendRequest(t, http.MethodDelete, server.URL+"/levels/1", "")
+	if code != http.StatusNotFound {
+		t.Errorf("expected 404 for delete of deleted level, got %d", code)
+	}
+
+	code, _ = sendRequest(t, http.MethodGet, server.URL+"/levels/2", "")
+	if code != http.StatusOK {
+		t.Errorf("expected another level of game to be kept, got %d", code)
+	}
+
+	// delete game with its levels
+	code, _ = sendRequest(t, http.MethodDelete, server.URL+"/games?creator=all%20ok%202&game=maze", "")
+	if code != http.StatusNoContent {
+		t.Errorf("unexpected delete game response %d", code)
+	}
+
+	code, _ = sendRequest(t, http.MethodGet, server.URL+"/levels/2", "")
+	if code != http.StatusNotFound {
+		t.Errorf("expected level of deleted game to be not found, got %d", code)
+	}
+
+	code, _ = sendRequest(t, http.MethodDelete, server.URL+"/games?creator=all%20ok%202&game=maze", "")
+	if code != http.StatusNotFound {
+		t.Errorf("expected 404 for delete of deleted game, got %d", code)
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	var (
+		server *httptest.Server
+		code   int
+		body   string
+	)
+
+	server = buildServer(t, model.NewMemoryStorage())
+
+	code, _ = sendRequest(t, http.MethodPost, server.URL, "{broken")
+	if code != http.StatusBadRequest {
+		t.Errorf("expected 400 for malformed json, got %d", code)
+	}
+
+	code, body = sendRequest(t, http.MethodPost, server.URL, readFile(t, "../testdata/data_not_rectangle.json"))
+	if (code != http.StatusUnprocessableEntity) || !strings.Contains(body, model.ViolationNotRectangular) {
+		t.Errorf("expected 422 with violation for invalid level, got %d: %s", code, body)
+	}
+
+	code, _ = sendRequest(t, http.MethodGet, server.URL, "")
+	if code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET request, got %d", code)
+	}
+}
+
+func TestHandlerSetConstraints(t *testing.T) {
+	var (
+		err error
+
+		cfg         *config.ConfType
+		handler     *ServerType
+		server      *httptest.Server
+		constraints config.ConstraintsType
+		code        int
+		body        string
+		output      []byte
+	)
+
+	cfg, err = config.BuildConfig("../")
+	if err != nil {
+		t.Error("[error] can't build config:", err)
+		t.FailNow()
+	}
+
+	handler = &ServerType{Storage: model.NewMemoryStorage(), Cfg: cfg}
+	server = httptest.NewServer(handler.Routes())
+	t.Cleanup(server.Close)
+
+	// level fits constraints of config file
+	code, body = sendRequest(t, http.MethodPost, server.URL, readFile(t, "../testdata/data_all_ok_1_1.json"))
+	if code != http.StatusCreated {
+		t.Errorf("expected 201 for valid level, got %d: %s", code, body)
+	}
+
+	// reloaded constraints are used by the next request, config itself stays untouched
+	constraints = cfg.Constraints
+	constraints.Dimension.Height.Max = 2
+	handler.SetConstraints(constraints)
+
+	code, body = sendRequest(t, http.MethodPost, server.URL, readFile(t, "../testdata/data_all_ok_1_1.json"))
+	if (code != http.StatusUnprocessableEntity) || !strings.Contains(body, model.ViolationTooManyLines) {
+		t.Errorf("expected 422 with violation after constraints reload, got %d: %s", code, body)
+	}
+
+	if cfg.Constraints.Dimension.Height.Max == 2 {
+		t.Error("config must not be changed by constraints reload")
+	}
+
+	// binary level is bounded by actual constraints while it's decoded, not by ones of start
+	output, err = codec.BinaryType{}.Encode([][]int{{1, 1, 1}, {1, 4, 5}, {1, 1, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	constraints.Dimension.Width.Max = 2
+	constraints.Profiles = nil
+	handler.SetConstraints(constraints)
+
+	code, body = sendContent(t, http.MethodPost, server.URL+"/msp", codec.MediaTypeBinary, string(output))
+	if (code != http.StatusBadRequest) || !strings.Contains(body, "bigger than 2") {
+		t.Errorf("expected 400 for binary level bigger than reloaded max side, got %d: %s", code, body)
+	}
+}
+
+func TestHandlerGameProfileMemory(t *testing.T) {
+	testGameProfile(t, model.NewMemoryStorage())
+}
+
+func TestHandlerGameProfileSQLite(t *testing.T) {
+	testGameProfile(t, buildSQLite(t))
+}
+
+func testGameProfile(t *testing.T, storage model.RepositoryType) {
+	var (
+		err error
+
+		cfg     *config.ConfType
+		server  *httptest.Server
+		profile config.ConstraintsType
+		games   []model.GameType
+		code    int
+		body    string
+	)
+
+	cfg, err = config.BuildConfig("../")
+	if err != nil {
+		t.Error("[error] can't build config:", err)
+		t.FailNow()
+	}
+
+	// small puzzle games have only few lines
+	profile = cfg.Constraints
+	profile.Dimension.Height.Max = 5
+	cfg.Constraints.Profiles = map[string]config.ConstraintsType{"puzzle": profile}
+
+	server = httptest.NewServer((&ServerType{Storage: storage, Cfg: cfg}).Routes())
+	t.Cleanup(server.Close)
+
+	code, body = sendRequest(t, http.MethodPost, server.URL, readFile(t, "../testdata/data_all_ok_1_1.json"))
+	if code != http.StatusCreated {
+		t.Errorf("expected 201 for valid level, got %d: %s", code, body)
+	}
+
+	code, body = sendRequest(t, http.MethodPatch, server.URL+"/games?creator=all%20ok%201&game=labyrinth", `{"profile": "dungeon"}`)
+	if code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for unknown profile, got %d: %s", code, body)
+	}
+
+	code, body = sendRequest(t, http.MethodPatch, server.URL+"/games?creator=all%20ok%201&game=labyrinth", `{"profile": "puzzle"}`)
+	if code != http.StatusOK {
+		t.Errorf("expected 200 for profile assignment, got %d: %s", code, body)
+	}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/games?creator=all%20ok%201", "")
+	err = json.Unmarshal([]byte(body), &games)
+	if (code != http.StatusOK) || (err != nil) || (len(games) != 1) || (games[0].Profile != "puzzle") {
+		t.Errorf("expected game with puzzle profile, got %d: %s", code, body)
+	}
+
+	// the same level is too large for game's profile now
+	code, body = sendRequest(t, http.MethodPost, server.URL, readFile(t, "../testdata/data_all_ok_1_1.json"))
+	if (code != http.StatusUnprocessableEntity) || !strings.Contains(body, model.ViolationTooManyLines) {
+		t.Errorf("expected 422 with violation of profile, got %d: %s", code, body)
+	}
+
+	// empty profile resets game to default constraints
+	code, body = sendRequest(t, http.MethodPatch, server.URL+"/games?creator=all%20ok%201&game=labyrinth", `{"profile": ""}`)
+	if code != http.StatusOK {
+		t.Errorf("expected 200 for profile reset, got %d: %s", code, body)
+	}
+
+	code, body = sendRequest(t, http.MethodPost, server.URL, readFile(t, "../testdata/data_all_ok_1_1.json"))
+	if code != http.StatusCreated {
+		t.Errorf("expected 201 with default constraints, got %d: %s", code, body)
+	}
+
+	// name and profile are changed together: taken name fails the whole change
+	code, body = sendRequest(t, http.MethodPost, server.URL, strings.Replace(readFile(t, "../testdata/data_all_ok_1_1.json"), `"labyrinth"`, `"maze"`, 1))
+	if code != http.StatusCreated {
+		t.Errorf("expected 201 for level of another game, got %d: %s", code, body)
+	}
+
+	code, body = sendRequest(t, http.MethodPatch, server.URL+"/games?creator=all%20ok%201&game=labyrinth", `{"game": "maze", "profile": "puzzle"}`)
+	if code != http.StatusConflict {
+		t.Errorf("expected 409 for taken name, got %d: %s", code, body)
+	}
+
+	// the current name is not a conflict
+	code, body = sendRequest(t, http.MethodPatch, server.URL+"/games?creator=all%20ok%201&game=labyrinth", `{"game": "labyrinth"}`)
+	if code != http.StatusOK {
+		t.Errorf("expected 200 for rename to the same name, got %d: %s", code, body)
+	}
+
+	games = nil
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/games?creator=all%20ok%201", "")
+	err = json.Unmarshal([]byte(body), &games)
+	if (code != http.StatusOK) || (err != nil) || (len(games) != 2) || (games[0].Profile != "") || (games[1].Profile != "") {
+		t.Errorf("expected games without profile after failed change, got %d: %s", code, body)
+	}
+
+	code, body = sendRequest(t, http.MethodPatch, server.URL+"/games?creator=all%20ok%201&game=labyrinth", `{"game": "castle", "profile": "puzzle"}`)
+	if (code != http.StatusOK) || !strings.Contains(body, `"Game":"castle"`) || !strings.Contains(body, `"Profile":"puzzle"`) {
+		t.Errorf("expected renamed game with profile, got %d: %s", code, body)
+	}
+}
+
+func TestHandlerASCII(t *testing.T) {
+	var (
+		server *httptest.Server
+		code   int
+		body   string
+		level  string
+	)
+
+	server = buildServer(t, model.NewMemoryStorage())
+
+	level = "####.###\n#......#\n#.###>##\n#...#.O#\n###.##.#\n#...#..#\n#.###.##\n#..@...#\n########\n"
+
+	code, body = sendContent(t, http.MethodPost, server.URL+"?creator=designer&game=sketch&level=1", "text/plain; charset=utf-8", level)
+	if (code != http.StatusCreated) || (body != "1") {
+		t.Errorf("expected 201 for ascii level, got %d: %s", code, body)
+	}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels/1?format=ascii", "")
+	if (code != http.StatusOK) || (body != level) {
+		t.Errorf("expected the same ascii level, got %d:\n%s", code, body)
+	}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels?creator=designer&game=sketch&level=1", "")
+	if (code != http.StatusOK) || !strings.Contains(body, `"Data":[[1,1,1,1,0,1,1,1]`) {
+		t.Errorf("expected level in json by default, got %d: %s", code, body)
+	}
+
+	code, body = sendContent(t, http.MethodPost, server.URL+"/msp", "text/plain", level)
+	if (code != http.StatusCreated) || !strings.Contains(body, `"status":"solved"`) {
+		t.Errorf("expected msp for ascii level, got %d: %s", code, body)
+	}
+
+	// level is checked before analysis, broken one isn't analyzed
+	code, body = sendContent(t, http.MethodPost, server.URL+"/msp", "text/plain", "#@#\n#.\n#E#\n")
+	if (code != http.StatusUnprocessableEntity) || !strings.Contains(body, "not_rectangular") {
+		t.Errorf("expected 422 for not rectangular level, got %d: %s", code, body)
+	}
+
+	code, body = sendContent(t, http.MethodPost, server.URL+"/msp", "text/plain", "@"+strings.Repeat(".", config.DefaultDimensionMax)+"E\n")
+	if (code != http.StatusUnprocessableEntity) || !strings.Contains(body, "line_too_long") {
+		t.Errorf("expected 422 for too long line, got %d: %s", code, body)
+	}
+
+	code, _ = sendContent(t, http.MethodPost, server.URL+"?creator=designer&game=sketch&level=2", "text/plain", "#x#\n")
+	if code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown character, got %d", code)
+	}
+
+	code, _ = sendContent(t, http.MethodPost, server.URL, "application/xml", "<level/>")
+	if code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415 for unknown content type, got %d", code)
+	}
+
+	code, _ = sendRequest(t, http.MethodGet, server.URL+"/levels/1?format=xml", "")
+	if code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown format, got %d", code)
+	}
+}
+
+func TestHandlerTiled(t *testing.T) {
+	var (
+		server *httptest.Server
+		code   int
+		body   string
+	)
+
+	tiled.Register(nil, "", 0)
+
+	server = buildServer(t, model.NewMemoryStorage())
+
+	// creator, game and number are taken from map properties
+	code, body = sendContent(t, http.MethodPost, server.URL, tiled.MediaTypeTMX, readFile(t, "../testdata/data_all_ok_1_1.tmx"))
+	if (code != http.StatusCreated) || (body != "1") {
+		t.Errorf("expected 201 for tmx level, got %d: %s", code, body)
+	}
+
+	// query overrides map properties
+	code, body = sendContent(t, http.MethodPost, server.URL+"?game=copy", tiled.MediaTypeTMX, readFile(t, "../testdata/data_all_ok_1_1.tmx"))
+	if (code != http.StatusCreated) || (body != "2") {
+		t.Errorf("expected 201 for tmx level in another game, got %d: %s", code, body)
+	}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels?creator=all%20ok%201&game=labyrinth&level=1", "")
+	if (code != http.StatusOK) || !strings.Contains(body, `"Data":[[1,1,1,1,0,1,1,1]`) {
+		t.Errorf("expected imported level, got %d: %s", code, body)
+	}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels/2?format=tmj", "")
+	if (code != http.StatusOK) || !strings.Contains(body, `"value": "copy"`) || !strings.Contains(body, `"type": "tilelayer"`) {
+		t.Errorf("expected level as tiled json map, got %d: %s", code, body)
+	}
+
+	// exported map is imported back as the same level
+	code, body = sendContent(t, http.MethodPost, server.URL, tiled.MediaTypeJSON, body)
+	if (code != http.StatusCreated) || (body != "2") {
+		t.Errorf("expected exported level to be stored as the same one, got %d: %s", code, body)
+	}
+
+	code, body = sendContent(t, http.MethodPost, server.URL, tiled.MediaTypeTMX, strings.Replace(readFile(t, "../testdata/data_all_ok_1_1.tmx"), "2,2,2,2,1", "2,2,2,2,9", 1))
+	if (code != http.StatusBadRequest) || !strings.Contains(body, "row 1, column 5") {
+		t.Errorf("expected 400 for unmapped tile, got %d: %s", code, body)
+	}
+}
+
+func TestHandlerBitmap(t *testing.T) {
+	var (
+		server *httptest.Server
+		code   int
+		body   string
+	)
+
+	bitmap.Register(nil, 0)
+
+	server = buildServer(t, model.NewMemoryStorage())
+
+	code, body = sendContent(t, http.MethodPost, server.URL+"?creator=designer&game=old&level=1", bitmap.MediaTypePNG, readFile(t, "../testdata/data_all_ok_1_1.png"))
+	if (code != http.StatusCreated) || (body != "1") {
+		t.Errorf("expected 201 for pixel-art level, got %d: %s", code, body)
+	}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels/1?format=png", "")
+	if (code != http.StatusOK) || (body != readFile(t, "../testdata/data_all_ok_1_1.png")) {
+		t.Errorf("expected the same pixel-art level, got %d", code)
+	}
+
+	code, body = sendContent(t, http.MethodPost, server.URL+"/msp", bitmap.MediaTypePNG, readFile(t, "../testdata/data_all_ok_1_1.png"))
+	if (code != http.StatusCreated) || !strings.Contains(body, `"status":"solved"`) {
+		t.Errorf("expected msp for pixel-art level, got %d: %s", code, body)
+	}
+
+	bitmap.Register(bitmap.ColorsType{"#3c3c46": 1}, 0)
+
+	code, body = sendContent(t, http.MethodPost, server.URL+"?creator=designer&game=old&level=2", bitmap.MediaTypePNG, readFile(t, "../testdata/data_all_ok_1_1.png"))
+	if (code != http.StatusBadRequest) || !strings.Contains(body, "unknown color #f5f5f0 in row 1, column 5") {
+		t.Errorf("expected 400 for unknown color, got %d: %s", code, body)
+	}
+
+	bitmap.Register(nil, 0)
+}
+
+func TestHandlerBinary(t *testing.T) {
+	var (
+		err error
+
+		storage *model.SQLType
+		server  *httptest.Server
+		code    int
+		body    string
+		stored  []byte
+		level   string
+	)
+
+	storage = buildSQLite(t)
+	server = buildServer(t, storage)
+
+	code, body = sendRequest(t, http.MethodPost, server.URL, readFile(t, "../testdata/data_all_ok_1_1.json"))
+	if (code != http.StatusCreated) || (body != "1") {
+		t.Errorf("expected 201 for valid level, got %d: %s", code, body)
+	}
+
+	// level data and its revision are stored in binary format
+	for _, query := range []string{"SELECT data FROM levels WHERE (id = 1)", "SELECT data FROM level_revisions WHERE (level_id = 1)"} {
+		err = storage.DB.QueryRow(query).Scan(&stored)
+		if (err != nil) || !codec.IsBinary(stored) {
+			t.Errorf("expected binary data for %q, got %v %q", query, err, stored)
+		}
+	}
+
+	code, level = sendRequest(t, http.MethodGet, server.URL+"/levels/1?format=binary", "")
+	if (code != http.StatusOK) || !codec.IsBinary([]byte(level)) {
+		t.Errorf("expected binary level, got %d: %q", code, level)
+	}
+
+	code, body = sendContent(t, http.MethodPost, server.URL+"?creator=designer&game=binary&level=1", codec.MediaTypeBinary, level)
+	if (code != http.StatusCreated) || (body != "2") {
+		t.Errorf("expected 201 for binary level, got %d: %s", code, body)
+	}
+
+	// data which was stored as json before binary format is still read
+	_, err = storage.DB.Exec("UPDATE levels SET data = '[[1,4,1],[1,0,1],[1,0,1]]' WHERE (id = 2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels/2", "")
+	if (code != http.StatusOK) || !strings.Contains(body, `"Data":[[1,4,1],[1,0,1],[1,0,1]]`) {
+		t.Errorf("expected level stored as json, got %d: %s", code, body)
+	}
+
+	code, _ = sendContent(t, http.MethodPost, server.URL+"?creator=designer&game=binary&level=2", codec.MediaTypeBinary, "[[1,4,1]]")
+	if code != http.StatusBadRequest {
+		t.Errorf("expected 400 for broken binary level, got %d", code)
+	}
+}
+
+func TestHandlerBodyTooLarge(t *testing.T) {
+	var (
+		err error
+
+		cfg    *config.ConfType
+		server *httptest.Server
+		code   int
+		body   string
+	)
+
+	cfg, err = config.BuildConfig("../")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Server.MaxBody = 64
+
+	server = httptest.NewServer((&ServerType{Storage: model.NewMemoryStorage(), Cfg: cfg}).Routes())
+	t.Cleanup(server.Close)
+
+	// body of each format is limited before it's decoded
+	code, body = sendRequest(t, http.MethodPost, server.URL, readFile(t, "../testdata/data_all_ok_1_1.json"))
+	if (code != http.StatusRequestEntityTooLarge) || !strings.Contains(body, "request_too_large") {
+		t.Errorf("expected 413 for big json level, got %d: %s", code, body)
+	}
+
+	code, body = sendContent(t, http.MethodPost, server.URL+"/msp", codec.MediaTypeBinary, "GJL"+strings.Repeat("\x00", 100))
+	if code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for big binary level, got %d: %s", code, body)
+	}
+
+	code, body = sendContent(t, http.MethodPost, server.URL+"/games/archive", archive.MediaType, strings.Repeat("PK", 100))
+	if (code != http.StatusRequestEntityTooLarge) || !strings.Contains(body, "request_too_large") {
+		t.Errorf("expected 413 for big archive, got %d: %s", code, body)
+	}
+
+	// changes of level, game and creator are limited the same way
+	for _, url := range []string{"/levels/1", "/games?creator=designer&game=labyrinth", "/creators?creator=designer"} {
+		code, body = sendRequest(t, http.MethodPatch, server.URL+url, `{"game": "`+strings.Repeat("a", 100)+`"}`)
+		if (code != http.StatusRequestEntityTooLarge) || !strings.Contains(body, "request_too_large") {
+			t.Errorf("expected 413 for big patch of %s, got %d: %s", url, code, body)
+		}
+	}
+
+	code, body = sendRequest(t, http.MethodPost, server.URL+"/msp", `{"data": [[1,1,1],[1,4,1],[1,5,1],[1,1,1]]}`)
+	if code != http.StatusCreated {
+		t.Errorf("expected 201 for small level, got %d: %s", code, body)
+	}
+}
+
+func TestHandlerArchiveMemory(t *testing.T) {
+	testArchive(t, model.NewMemoryStorage())
+}
+
+func TestHandlerArchiveSQLite(t *testing.T) {
+	testArchive(t, buildSQLite(t))
+}
+
+func testArchive(t *testing.T, storage model.RepositoryType) {
+	var (
+		err error
+
+		server    *httptest.Server
+		code      int
+		body      string
+		exported  string
+		read      model.GameArchiveType
+		result    model.ImportResultType
+		reply     *http.Response
+		broken    bytes.Buffer
+		levelData string
+	)
+
+	server = buildServer(t, storage)
+
+	for _, name := range []string{"../testdata/data_all_ok_1_2.json", "../testdata/data_all_ok_1_1.json"} {
+		code, body = sendRequest(t, http.MethodPost, server.URL, readFile(t, name))
+		if code != http.StatusCreated {
+			t.Errorf("expected 201 for valid level, got %d: %s", code, body)
+		}
+	}
+
+	reply, err = http.Get(server.URL + "/games/archive?creator=all%20ok%201&game=labyrinth")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = reply.Body.Close()
+
+	if (reply.StatusCode != http.StatusOK) || (reply.Header.Get("Content-Disposition") != `attachment; filename="labyrinth.zip"`) {
+		t.Errorf("expected archive as attachment, got %d %v", reply.StatusCode, reply.Header)
+	}
+
+	code, exported = sendRequest(t, http.MethodGet, server.URL+"/games/archive?creator=all%20ok%201&game=labyrinth", "")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200 for archive, got %d: %s", code, exported)
+	}
+
+	read, err = archive.Read([]byte(exported), archive.LimitsType{Size: 1 << 20, Side: 100})
+	if (err != nil) || (len(read.Levels) != 2) || (read.Levels[0].Level != 1) || (read.Levels[1].Level != 2) {
+		t.Errorf("expected archive with levels in order, got %v %+v", err, read)
+	}
+
+	code, _ = sendRequest(t, http.MethodGet, server.URL+"/games/archive?creator=all%20ok%201&game=unknown", "")
+	if code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown game, got %d", code)
+	}
+
+	// existing game isn't changed without conflict option
+	code, body = sendContent(t, http.MethodPost, server.URL+"/games/archive", archive.MediaType, exported)
+	if code != http.StatusConflict {
+		t.Errorf("expected 409 for existing game, got %d: %s", code, body)
+	}
+
+	code, body = sendContent(t, http.MethodPost, server.URL+"/games/archive?conflict=merge", archive.MediaType, exported)
+	if code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown conflict option, got %d: %s", code, body)
+	}
+
+	code, body = sendContent(t, http.MethodPost, server.URL+"/games/archive?conflict=skip", archive.MediaType, exported)
+	if (code != http.StatusOK) || !strings.Contains(body, `"Status":"skipped"`) {
+		t.Errorf("expected skipped import, got %d: %s", code, body)
+	}
+
+	for _, game := range []string{"labyrinth (2)", "labyrinth (3)"} {
+		code, body = sendContent(t, http.MethodPost, server.URL+"/games/archive?conflict=rename", archive.MediaType, exported)
+
+		result = model.ImportResultType{}
+		err = json.Unmarshal([]byte(body), &result)
+		if (code != http.StatusCreated) || (err != nil) || (result.Game != game) || (result.Status != model.ImportRenamed) || (len(result.Levels) != 2) {
+			t.Errorf("expected import as %q, got %d: %s", game, code, body)
+		}
+	}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels?creator=all%20ok%201&game=labyrinth%20(3)&level=2", "")
+	if (code != http.StatusOK) || !strings.Contains(body, `"Level":2`) {
+		t.Errorf("expected level of renamed game, got %d: %s", code, body)
+	}
+
+	// overwrite adds revisions to levels of game: level 3 isn't in archive, so it's kept as it is
+	levelData = strings.Replace(readFile(t, "../testdata/data_all_ok_1_1.json"), `"level": 1`, `"level": 3`, 1)
+
+	code, body = sendRequest(t, http.MethodPost, server.URL, levelData)
+	if code != http.StatusCreated {
+		t.Errorf("expected 201 for valid level, got %d: %s", code, body)
+	}
+
+	code, body = sendContent(t, http.MethodPost, server.URL+"/games/archive?conflict=overwrite", archive.MediaType, exported)
+
+	result = model.ImportResultType{}
+	err = json.Unmarshal([]byte(body), &result)
+	if (code != http.StatusOK) || (err != nil) || (result.Status != model.ImportOverwritten) || (len(result.Levels) != 2) {
+		t.Fatalf("expected overwritten import, got %d: %s", code, body)
+	}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels?creator=all%20ok%201&game=labyrinth", "")
+	if (code != http.StatusOK) || (strings.Count(body, `"Level":`) != 3) {
+		t.Errorf("expected levels of archive and level 3, got %d: %s", code, body)
+	}
+
+	code, body = sendRequest(t, http.MethodGet, fmt.Sprintf("%s/levels/%d/revisions", server.URL, result.Levels[0]), "")
+	if (code != http.StatusOK) || (strings.Count(body, `"Revision":`) != 2) {
+		t.Errorf("expected uploaded and imported revisions of level, got %d: %s", code, body)
+	}
+
+	// invalid level fails whole import, violations are marked by level number
+	read.Game = "broken"
+	read.Levels[1].Data = [][]int{{1, 9, 1}}
+
+	err = archive.Write(&broken, read, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, body = sendContent(t, http.MethodPost, server.URL+"/games/archive", archive.MediaType, broken.String())
+	if (code != http.StatusUnprocessableEntity) || !strings.Contains(body, `"level":2`) {
+		t.Errorf("expected 422 for invalid level, got %d: %s", code, body)
+	}
+
+	code, _ = sendRequest(t, http.MethodGet, server.URL+"/games/archive?creator=all%20ok%201&game=broken", "")
+	if code != http.StatusNotFound {
+		t.Errorf("expected no game after failed import, got %d", code)
+	}
+
+	read.Profile = "dungeon"
+	broken.Reset()
+
+	err = archive.Write(&broken, read, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, body = sendContent(t, http.MethodPost, server.URL+"/games/archive", archive.MediaType, broken.String())
+	if (code != http.StatusUnprocessableEntity) || !strings.Contains(body, "unknown_profile") {
+		t.Errorf("expected 422 for unknown profile, got %d: %s", code, body)
+	}
+
+	code, body = sendContent(t, http.MethodPost, server.URL+"/games/archive", archive.MediaType, "not zip")
+	if code != http.StatusBadRequest {
+		t.Errorf("expected 400 for broken archive, got %d: %s", code, body)
+	}
+}
+
+func TestHandlerImage(t *testing.T) {
+	var (
+		server *httptest.Server
+		code   int
+		body   string
+	)
+
+	server = buildServer(t, model.NewMemoryStorage())
+
+	code, body = sendRequest(t, http.MethodPost, server.URL, readFile(t, "../testdata/data_all_ok_1_1.json"))
+	if code != http.StatusCreated {
+		t.Errorf("expected 201 for valid level, got %d: %s", code, body)
+	}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels/1/image", "")
+	if (code != http.StatusOK) || !strings.HasPrefix(body, "\x89PNG") {
+		t.Errorf("expected png image by default, got %d", code)
+	}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels/1/image?format=svg&cell=8", "")
+	if (code != http.StatusOK) || !strings.Contains(body, `class="path"`) {
+		t.Errorf("expected svg image with path, got %d: %s", code, body)
+	}
+
+	code, body = sendRequest(t, http.MethodGet, server.URL+"/levels/1/image?format=svg&path=false", "")
+	if (code != http.StatusOK) || strings.Contains(body, `class="path"`) {
+		t.Errorf("expected svg image without path, got %d: %s", code, body)
+	}
+
+	code, _ = sendRequest(t, http.MethodGet, server.URL+"/levels/1/image?format=gif", "")
+	if code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown format, got %d", code)
+	}
+
+	code, _ = sendRequest(t, http.MethodGet, server.URL+"/levels/2/image", "")
+	if code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown level, got %d", code)
+	}
+}
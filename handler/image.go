@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"fmt"
+	"greenjade/model"
+	"greenjade/render"
+	"net/http"
+	"strconv"
+)
+
+// draw stored level as image:
+// GET /levels/{id}/image?format=png|svg&cell=N&path=false - png by default, path is drawn by default if level is solved.
+func (server *ServerType) handlerImage(w http.ResponseWriter, r *http.Request, id int64) {
+	var (
+		err error
+
+		level   model.LevelType
+		levelId int64
+		options render.OptionsType
+		draw    bool
+
+		format    string
+		output    []byte
+		mediaType string
+	)
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "I'm ready to GET only")
+		return
+	}
+
+	draw = true
+	if r.URL.Query().Get("path") != "" {
+		draw, err = strconv.ParseBool(r.URL.Query().Get("path"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", "path must be boolean")
+			return
+		}
+	}
+
+	if r.URL.Query().Get("cell") != "" {
+		options.CellSize, err = strconv.Atoi(r.URL.Query().Get("cell"))
+		if (err != nil) || (options.CellSize < 1) || (options.CellSize > render.MaxCellSize) {
+			writeError(w, http.StatusBadRequest, "bad_request", fmt.Sprintf("cell must be integer in range 1..%d", render.MaxCellSize))
+			return
+		}
+	}
+
+	levelId = server.Storage.LoadLevelById(&level, id)
+	if levelId < 0 {
+		writeError(w, http.StatusInternalServerError, "error", "can't fetch level")
+		return
+	}
+
+	if levelId == 0 {
+		writeError(w, http.StatusNotFound, "not_found", "level not found")
+		return
+	}
+
+	// stored analysis has path only for solved level
+	if draw {
+		options.Path = level.Analysis.Path
+	}
+
+	format = r.URL.Query().Get("format")
+	if format == "" {
+		format = render.FormatPNG
+	}
+
+	output, mediaType, err = render.Render(format, level.Data, options)
+	if err == render.ErrUnknownFormat {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	if err != nil {
+		fmt.Println("[error] render level:", err)
+		writeError(w, http.StatusInternalServerError, "error", "can't render level")
+		return
+	}
+
+	fmt.Println("level id:", levelId, "image:", mediaType)
+
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(output)
+	if err != nil {
+		fmt.Println("[error] write image response:", err)
+	}
+}
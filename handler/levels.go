@@ -0,0 +1,340 @@
+package handler
+
+import (
+	"fmt"
+	"greenjade/model"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// filtering request type and route request for stored levels:
+// GET /levels/{id} - level by id (?format=ascii for level data as character map);
+// GET /levels?creator=...&game=...&level=... - level by creator, game and level number;
+// GET /levels?creator=...&game=... - all levels of game ordered by level number;
+// DELETE /levels/{id} - delete level;
+// PATCH /levels/{id} with body {"level": N} - change level number;
+// GET /levels/{id}/image?format=png|svg - level drawn as image, see handlerImage;
+// /levels/{id}/... - level's revisions, see handlerRevisions.
+func (server *ServerType) HandlerLevels(w http.ResponseWriter, r *http.Request) {
+	var (
+		err, status error
+
+		code  int
+		id    int64
+		path  string
+		parts []string
+
+		level model.LevelType
+	)
+
+	fmt.Println()
+
+	path = strings.Trim(strings.TrimPrefix(r.URL.Path, "/levels"), "/")
+	if path == "" {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "I'm ready to GET only")
+			return
+		}
+
+		server.handlerLevelsQuery(w, r)
+		return
+	}
+
+	parts = strings.Split(path, "/")
+
+	id, err = strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		fmt.Println("[error] parse level id:", err)
+		writeError(w, http.StatusBadRequest, "bad_request", "level id must be integer")
+		return
+	}
+
+	// image of level
+	if (len(parts) == 2) && (parts[1] == "image") {
+		server.handlerImage(w, r, id)
+		return
+	}
+
+	// level's revisions have their own routes
+	if len(parts) > 1 {
+		server.handlerRevisions(w, r, id, parts[1:])
+		return
+	}
+
+	level = model.LevelType{Id: id}
+
+	switch r.Method {
+	case http.MethodDelete:
+		fmt.Println("delete level id:", id)
+		writeModelResult(w, server.Storage.DeleteLevel(&level), http.StatusNoContent, nil)
+
+	case http.MethodPatch:
+		var (
+			changes model.LevelType
+		)
+
+		// request body contains only changed fields
+		code, err = server.decodeJSON(w, r, &changes)
+		if err != nil {
+			fmt.Println("[error] decode request params:", err)
+			writeError(w, code, decodeStatus(code), fmt.Sprintf("can't decode request body: %s", err.Error()))
+			return
+		}
+
+		fmt.Println("renumber level id:", id, "to:", changes.Level)
+
+		status = server.Storage.RenumberLevel(&level, changes.Level)
+		if status != nil {
+			writeModelResult(w, status, http.StatusOK, nil)
+			return
+		}
+
+		// respond with actual level data
+		server.writeLevel(w, r.URL.Query().Get("format"), model.LevelType{}, func(level *model.LevelType) int64 {
+			return server.Storage.LoadLevelById(level, id)
+		})
+
+	case http.MethodGet:
+		server.writeLevel(w, r.URL.Query().Get("format"), level, func(level *model.LevelType) int64 {
+			return server.Storage.LoadLevelById(level, id)
+		})
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "I'm ready to GET, DELETE and PATCH only")
+	}
+}
+
+// fetch level by creator, game and level number, or list all levels of game if level number is omitted.
+func (server *ServerType) handlerLevelsQuery(w http.ResponseWriter, r *http.Request) {
+	var (
+		err error
+
+		query url.Values
+		game  model.GameType
+
+		levels []model.LevelType
+		number int64
+		gameId int64
+	)
+
+	query = r.URL.Query()
+
+	if (query.Get("creator") == "") || (query.Get("game") == "") {
+		writeError(w, http.StatusBadRequest, "bad_request", "creator and game are required")
+		return
+	}
+
+	// single level is requested
+	if query.Get("level") != "" {
+		number, err = strconv.ParseInt(query.Get("level"), 10, 64)
+		if err != nil {
+			fmt.Println("[error] parse level number:", err)
+			writeError(w, http.StatusBadRequest, "bad_request", "level must be integer")
+			return
+		}
+
+		server.writeLevel(w, query.Get("format"), model.LevelType{Creator: query.Get("creator"), Game: query.Get("game"), Level: number},
+			func(level *model.LevelType) int64 {
+				return server.Storage.LoadLevelByNumber(level)
+			})
+
+		return
+	}
+
+	game = model.GameType{Creator: query.Get("creator"), Game: query.Get("game")}
+
+	levels, gameId = server.Storage.GetLevels(&game)
+	if gameId < 0 {
+		writeError(w, http.StatusInternalServerError, "error", "can't fetch levels")
+		return
+	}
+
+	if gameId == 0 {
+		writeError(w, http.StatusNotFound, "not_found", "game not found")
+		return
+	}
+
+	// list of levels has no single level data, so only json is available
+	if (query.Get("format") != "") && (query.Get("format") != FormatJSON) {
+		writeError(w, http.StatusBadRequest, "bad_request", "list of levels is available in json format only")
+		return
+	}
+
+	fmt.Println("game:", game.Game, "levels:", len(levels))
+
+	writeJSON(w, http.StatusOK, levels)
+}
+
+// changed fields of game, profile is pointer because empty profile resets it to default constraints
+type gameChangesType struct {
+	Game    string  `json:"game"`
+	Profile *string `json:"profile"`
+}
+
+// filtering request type and route request for games:
+// GET /games?creator=... - all games of creator;
+// DELETE /games?creator=...&game=... - delete game with all its levels;
+// PATCH /games?creator=...&game=... with body {"game": "...", "profile": "..."} - rename game and/or assign
+// constraints profile (empty profile means default constraints) in single transaction.
+func (server *ServerType) HandlerGames(w http.ResponseWriter, r *http.Request) {
+	var (
+		err, status error
+
+		code    int
+		creator model.CreatorType
+		game    model.GameType
+		changes gameChangesType
+
+		games     []model.GameType
+		creatorId int64
+	)
+
+	fmt.Println()
+
+	if r.URL.Query().Get("creator") == "" {
+		writeError(w, http.StatusBadRequest, "bad_request", "creator is required")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodDelete, http.MethodPatch:
+		if r.URL.Query().Get("game") == "" {
+			writeError(w, http.StatusBadRequest, "bad_request", "game is required")
+			return
+		}
+
+		game = model.GameType{Creator: r.URL.Query().Get("creator"), Game: r.URL.Query().Get("game")}
+
+		if r.Method == http.MethodDelete {
+			fmt.Println("delete game:", game.Game, "creator:", game.Creator)
+			writeModelResult(w, server.Storage.DeleteGame(&game), http.StatusNoContent, nil)
+			return
+		}
+
+		// request body contains only changed fields
+		code, err = server.decodeJSON(w, r, &changes)
+		if err != nil {
+			fmt.Println("[error] decode request params:", err)
+			writeError(w, code, decodeStatus(code), fmt.Sprintf("can't decode request body: %s", err.Error()))
+			return
+		}
+
+		if (changes.Game == "") && (changes.Profile == nil) {
+			writeError(w, http.StatusBadRequest, "bad_request", "new game name or profile is required")
+			return
+		}
+
+		// only profile from config can be assigned, empty one means default constraints
+		if (changes.Profile != nil) && !server.Constraints().HasProfile(*changes.Profile) {
+			writeError(w, http.StatusUnprocessableEntity, "unknown_profile", fmt.Sprintf("constraints profile %q is not found in config", *changes.Profile))
+			return
+		}
+
+		if changes.Game != "" {
+			fmt.Println("rename game:", game.Game, "creator:", game.Creator, "to:", changes.Game)
+		}
+
+		if changes.Profile != nil {
+			fmt.Println("set game profile:", game.Game, "creator:", game.Creator, "profile:", *changes.Profile)
+		}
+
+		// name and profile are changed together or not at all
+		status = server.Storage.UpdateGame(&game, changes.Game, changes.Profile)
+		writeModelResult(w, status, http.StatusOK, &game)
+		return
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "I'm ready to GET, DELETE and PATCH only")
+		return
+	}
+
+	creator = model.CreatorType{Creator: r.URL.Query().Get("creator")}
+
+	games, creatorId = server.Storage.GetGames(&creator)
+	if creatorId < 0 {
+		writeError(w, http.StatusInternalServerError, "error", "can't fetch games")
+		return
+	}
+
+	if creatorId == 0 {
+		writeError(w, http.StatusNotFound, "not_found", "creator not found")
+		return
+	}
+
+	fmt.Println("creator:", creator.Creator, "games:", len(games))
+
+	writeJSON(w, http.StatusOK, games)
+}
+
+// filtering request type and route request for creators:
+// DELETE /creators?creator=... - delete creator with all games and levels;
+// PATCH /creators?creator=... with body {"creator": "..."} - rename creator.
+func (server *ServerType) HandlerCreators(w http.ResponseWriter, r *http.Request) {
+	var (
+		err error
+
+		code    int
+		creator model.CreatorType
+		changes model.CreatorType
+	)
+
+	fmt.Println()
+
+	if (r.Method != http.MethodDelete) && (r.Method != http.MethodPatch) {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "I'm ready to DELETE and PATCH only")
+		return
+	}
+
+	if r.URL.Query().Get("creator") == "" {
+		writeError(w, http.StatusBadRequest, "bad_request", "creator is required")
+		return
+	}
+
+	creator = model.CreatorType{Creator: r.URL.Query().Get("creator")}
+
+	if r.Method == http.MethodDelete {
+		fmt.Println("delete creator:", creator.Creator)
+		writeModelResult(w, server.Storage.DeleteCreator(&creator), http.StatusNoContent, nil)
+		return
+	}
+
+	// request body contains only changed fields
+	code, err = server.decodeJSON(w, r, &changes)
+	if err != nil {
+		fmt.Println("[error] decode request params:", err)
+		writeError(w, code, decodeStatus(code), fmt.Sprintf("can't decode request body: %s", err.Error()))
+		return
+	}
+
+	if changes.Creator == "" {
+		writeError(w, http.StatusBadRequest, "bad_request", "new creator name is required")
+		return
+	}
+
+	fmt.Println("rename creator:", creator.Creator, "to:", changes.Creator)
+	writeModelResult(w, server.Storage.RenameCreator(&creator, changes.Creator), http.StatusOK, &creator)
+}
+
+// load single level by specific loader and write it as response in requested format (json by default).
+func (server *ServerType) writeLevel(w http.ResponseWriter, format string, level model.LevelType, load func(level *model.LevelType) int64) {
+	var (
+		levelId int64
+	)
+
+	levelId = load(&level)
+	if levelId < 0 {
+		writeError(w, http.StatusInternalServerError, "error", "can't fetch level")
+		return
+	}
+
+	if levelId == 0 {
+		writeError(w, http.StatusNotFound, "not_found", "level not found")
+		return
+	}
+
+	fmt.Println("level id:", levelId)
+
+	writeLevelFormat(w, format, level)
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"greenjade/analyze"
+	"greenjade/model"
+	"net/http"
+)
+
+// structure describe response with error, violations are listed only for invalid level
+type ErrorResponseType struct {
+	Status     string                `json:"status"`
+	Error      string                `json:"error"`
+	Violations []model.ViolationType `json:"violations,omitempty"`
+}
+
+// encode object into json and write it with specific http code.
+func writeJSON(w http.ResponseWriter, code int, obj interface{}) {
+	var (
+		err error
+
+		response []byte
+	)
+
+	response, err = json.Marshal(obj)
+	if err != nil {
+		fmt.Println("[error] encode json response:", err)
+		http.Error(w, `{"status":"error","error":"can't encode response"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	_, err = w.Write(response)
+	if err != nil {
+		fmt.Println("[error] write json response:", err)
+	}
+}
+
+// write error with short status code and message as json body with specific http code.
+func writeError(w http.ResponseWriter, code int, status, message string) {
+	writeJSON(w, code, ErrorResponseType{Status: status, Error: message})
+}
+
+// write all violations of level constraints as json body with 422 code, other errors are unexpected.
+func writeValidationError(w http.ResponseWriter, status error) {
+	var (
+		validation *model.ValidationErrorType
+		ok         bool
+	)
+
+	validation, ok = status.(*model.ValidationErrorType)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "error", status.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusUnprocessableEntity, ErrorResponseType{Status: "invalid_level", Error: validation.Error(), Violations: validation.Violations})
+}
+
+// map result of model operation to http code. on success write object as json body (or nothing if it's nil).
+func writeModelResult(w http.ResponseWriter, status error, code int, obj interface{}) {
+	switch status {
+	case nil:
+		if obj == nil {
+			w.WriteHeader(code)
+			return
+		}
+
+		writeJSON(w, code, obj)
+	case model.ErrNotFound:
+		writeError(w, http.StatusNotFound, "not_found", status.Error())
+	case model.ErrConflict:
+		writeError(w, http.StatusConflict, "conflict", status.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "error", status.Error())
+	}
+}
+
+// map level analysis status to http code and write it as json error body.
+func writeAnalyzeError(w http.ResponseWriter, status error) {
+	var (
+		code int
+
+		analyzeStatus analyze.StatusType
+		ok            bool
+	)
+
+	analyzeStatus, ok = status.(analyze.StatusType)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponseType{Status: "error", Error: status.Error()})
+		return
+	}
+
+	switch analyzeStatus {
+	case analyze.StatusNoHero, analyze.StatusMultipleHeroes, analyze.StatusNoExit,
+		analyze.StatusExitUnreachable, analyze.StatusNoSurvivablePath:
+		// request is well-formed, but level can't be analyzed or finished
+		code = http.StatusUnprocessableEntity
+	default:
+		code = http.StatusInternalServerError
+	}
+
+	writeJSON(w, code, ErrorResponseType{Status: analyzeStatus.Code(), Error: analyzeStatus.Error()})
+}
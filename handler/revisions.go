@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"fmt"
+	"greenjade/model"
+	"net/http"
+	"strconv"
+)
+
+// structure describe response with difference between two revisions of level
+type DiffResponseType struct {
+	From  int64                `json:"from"`
+	To    int64                `json:"to"`
+	Cells []model.CellDiffType `json:"cells"`
+}
+
+// route request for level's revisions:
+// GET /levels/{id}/revisions - all revisions of level without data;
+// GET /levels/{id}/revisions/{n} - specific revision with data;
+// GET /levels/{id}/diff?from=N&to=M - cell-by-cell difference between two revisions;
+// POST /levels/{id}/rollback?revision=N - make older revision actual (as new revision).
+func (server *ServerType) handlerRevisions(w http.ResponseWriter, r *http.Request, id int64, parts []string) {
+	var (
+		err, status error
+
+		level    model.LevelType
+		revision model.RevisionType
+
+		revisions []model.RevisionType
+		number    int64
+		levelId   int64
+	)
+
+	level = model.LevelType{Id: id}
+
+	switch {
+	case (parts[0] == "revisions") && (len(parts) == 1) && (r.Method == http.MethodGet):
+		revisions, levelId = server.Storage.GetRevisions(&level)
+		if levelId < 0 {
+			writeError(w, http.StatusInternalServerError, "error", "can't fetch revisions")
+			return
+		}
+
+		if levelId == 0 {
+			writeError(w, http.StatusNotFound, "not_found", "level not found")
+			return
+		}
+
+		fmt.Println("level id:", id, "revisions:", len(revisions))
+		writeJSON(w, http.StatusOK, revisions)
+
+	case (parts[0] == "revisions") && (len(parts) == 2) && (r.Method == http.MethodGet):
+		number, err = strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			fmt.Println("[error] parse revision number:", err)
+			writeError(w, http.StatusBadRequest, "bad_request", "revision must be integer")
+			return
+		}
+
+		revision = model.RevisionType{LevelId: id, Revision: number}
+		if !server.loadRevision(w, &revision) {
+			return
+		}
+
+		fmt.Println("level id:", id, "revision:", number)
+		writeJSON(w, http.StatusOK, revision)
+
+	case (parts[0] == "diff") && (len(parts) == 1) && (r.Method == http.MethodGet):
+		server.handlerDiff(w, r, id)
+
+	case (parts[0] == "rollback") && (len(parts) == 1) && (r.Method == http.MethodPost):
+		number, err = strconv.ParseInt(r.URL.Query().Get("revision"), 10, 64)
+		if err != nil {
+			fmt.Println("[error] parse revision number:", err)
+			writeError(w, http.StatusBadRequest, "bad_request", "revision must be integer")
+			return
+		}
+
+		fmt.Println("rollback level id:", id, "to revision:", number)
+
+		status = server.Storage.RollbackLevel(&level, number, server.Constraints())
+		if status != nil {
+			writeModelResult(w, status, http.StatusOK, nil)
+			return
+		}
+
+		// respond with actual level data
+		server.writeLevel(w, r.URL.Query().Get("format"), model.LevelType{}, func(level *model.LevelType) int64 {
+			return server.Storage.LoadLevelById(level, id)
+		})
+
+	default:
+		writeError(w, http.StatusNotFound, "not_found", "unknown route")
+	}
+}
+
+// load two revisions of level and compare them cell by cell.
+func (server *ServerType) handlerDiff(w http.ResponseWriter, r *http.Request, id int64) {
+	var (
+		err error
+
+		from, to model.RevisionType
+	)
+
+	from = model.RevisionType{LevelId: id}
+	to = model.RevisionType{LevelId: id}
+
+	from.Revision, err = strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+	if err == nil {
+		to.Revision, err = strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+	}
+
+	if err != nil {
+		fmt.Println("[error] parse revision numbers:", err)
+		writeError(w, http.StatusBadRequest, "bad_request", "from and to must be integer revision numbers")
+		return
+	}
+
+	if !server.loadRevision(w, &from) || !server.loadRevision(w, &to) {
+		return
+	}
+
+	fmt.Println("level id:", id, "diff:", from.Revision, "->", to.Revision)
+
+	writeJSON(w, http.StatusOK, DiffResponseType{From: from.Revision, To: to.Revision, Cells: model.DiffData(from.Data, to.Data)})
+}
+
+// load revision and write error response if it's impossible.
+// return true if revision was loaded
+func (server *ServerType) loadRevision(w http.ResponseWriter, revision *model.RevisionType) bool {
+	switch server.Storage.LoadRevision(revision) {
+	case -1:
+		writeError(w, http.StatusInternalServerError, "error", "can't fetch revision")
+		return false
+	case 0:
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("revision %d not found", revision.Revision))
+		return false
+	}
+
+	return true
+}
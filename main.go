@@ -5,58 +5,188 @@ import (
 	"database/sql"
 	"flag"
 	"fmt"
+	"greenjade/bitmap"
+	"greenjade/codec"
 	"greenjade/config"
 	"greenjade/database"
 	"greenjade/handler"
+	"greenjade/model"
+	"greenjade/tiled"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
 	var (
 		err error
 
-		port *int
-		cfg  *config.ConfType
-		db   *sql.DB
+		migrate *bool
+		cfg     *config.ConfType
+		db      *sql.DB
 
-		server handler.ServerType
+		storage model.RepositoryType
+		server  handler.ServerType
 	)
 
+	// commands are run instead of service
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[2:]))
+		}
+	}
+
+	migrate = flag.Bool("migrate", false, "apply pending db schema migrations and exit")
+
 	fmt.Println("config build...")
 
-	cfg = config.BuildConfig(config.DefaultPath)
-	if cfg == nil {
+	// config file, environment and flags are combined by config package
+	cfg, err = config.Load(flag.CommandLine, os.Args[1:], config.DefaultPath)
+	if err != nil {
+		fmt.Println("[error] config build:", err)
 		return
 	}
 
 	fmt.Println("config build: done")
-	fmt.Println("connect to db...")
 
-	db = database.OpenDB(cfg.Database)
-	if db == nil {
+	// some level formats depend on config
+	registerCodecs(cfg)
+
+	if *migrate && (cfg.Storage.Driver == model.StorageMemory) {
+		fmt.Println("[error] in-memory storage has no schema to migrate")
 		return
 	}
 
-	defer func() {
-		if err := db.Close(); err != nil {
-			fmt.Println("[error] clear memory db", err)
+	storage, db = openStorage(cfg)
+	if storage == nil {
+		return
+	}
+
+	if db != nil {
+		defer func() {
+			if err := db.Close(); err != nil {
+				fmt.Println("[error] clear memory db", err)
+			}
+		}()
+	}
+
+	// levels stored before analysis columns existed are analyzed once, after migrations
+	if db != nil {
+		count := model.BackfillAnalysis(db, cfg.Constraints)
+		if count < 0 {
+			fmt.Println("[error] analyze stored levels failed")
+			return
+		}
+
+		if count > 0 {
+			fmt.Println("analyze stored levels: done, count:", count)
 		}
-	}()
+	}
 
-	server = handler.ServerType{DB: db, Cfg: cfg}
+	// schema is already up to date, migrations are applied on opening db
+	if *migrate {
+		fmt.Println("migrations: done")
+		return
+	}
 
-	fmt.Println("connect to db: done")
-	port = flag.Int("p", 9080, "service port")
-	flag.Parse()
+	server = handler.ServerType{Storage: storage, Cfg: cfg}
 
-	fmt.Println("service run on port", *port)
-	fmt.Println("to stop the service, press [Ctrl+C]")
+	go reloadOnSignal(&server, cfg)
 
-	http.HandleFunc("/", server.Handler)
-	http.HandleFunc("/msp", server.HandlerMSP)
+	fmt.Println("service run on port", cfg.Server.Port)
+	fmt.Println("to stop the service, press [Ctrl+C]")
 
-	err = http.ListenAndServe(fmt.Sprintf(":%d", *port), nil)
+	err = http.ListenAndServe(fmt.Sprintf(":%d", cfg.Server.Port), server.Routes())
 	if err != nil {
 		fmt.Println("error:", err)
 	}
 }
+
+// register codecs which need config: binary levels with max side, Tiled maps with tile mapping, pixel-art images
+// with colors. max side of start is used by commands, service sets actual one for each decoded level
+func registerCodecs(cfg *config.ConfType) {
+	codec.Register(codec.BinaryType{MaxSide: cfg.Constraints.MaxSide()})
+	tiled.Register(cfg.Tiled.Tiles, cfg.Tiled.Tileset, cfg.Constraints.MaxSide())
+	bitmap.Register(cfg.Bitmap.Colors, cfg.Constraints.MaxSide())
+}
+
+// choose storage for levels by config, db is needed only for postgres and sqlite ones.
+// pending migrations are applied on opening db.
+// return storage and db which must be closed by caller (nil for in-memory storage), storage is nil on error
+func openStorage(cfg *config.ConfType) (storage model.RepositoryType, db *sql.DB) {
+	switch cfg.Storage.Driver {
+	case model.StorageMemory:
+		fmt.Println("use in-memory storage, data will be lost on restart")
+		return model.NewMemoryStorage(), nil
+
+	case model.StoragePostgres:
+		fmt.Println("connect to db...")
+
+		db = database.OpenDB(cfg.Database)
+		if db == nil {
+			return nil, nil
+		}
+
+		fmt.Println("connect to db: done")
+
+		if database.Migrate(db, database.DialectPostgres) < 0 {
+			fmt.Println("[error] migrate db failed")
+
+			if err := db.Close(); err != nil {
+				fmt.Println("[error] clear memory db", err)
+			}
+
+			return nil, nil
+		}
+
+		return &model.SQLType{DB: db}, db
+
+	case model.StorageSQLite:
+		fmt.Println("open sqlite db file", cfg.Storage.File, "...")
+
+		db = database.OpenSQLite(cfg.Storage.File)
+		if db == nil {
+			return nil, nil
+		}
+
+		fmt.Println("open sqlite db file: done")
+
+		return &model.SQLType{DB: db}, db
+	}
+
+	fmt.Println("[error] unknown storage driver:", cfg.Storage.Driver)
+
+	return nil, nil
+}
+
+// wait for SIGHUP and reload config, only constraints are replaced without restart.
+// if new config is invalid, previous one stays in use
+func reloadOnSignal(server *handler.ServerType, cfg *config.ConfType) {
+	var (
+		signals chan os.Signal
+	)
+
+	signals = make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		var (
+			err error
+
+			actual *config.ConfType
+		)
+
+		fmt.Println("config reload...")
+
+		actual, err = cfg.Reload()
+		if err != nil {
+			fmt.Println("[error] config reload, previous config is kept:", err)
+			continue
+		}
+
+		server.SetConstraints(actual.Constraints)
+
+		fmt.Println("config reload: done, constraints:", actual.Constraints)
+	}
+}
@@ -0,0 +1,180 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"greenjade/config"
+)
+
+const (
+	ConflictFail      = ""          // import of existing game fails with ErrConflict
+	ConflictSkip      = "skip"      // existing game is kept as it is, archive is not imported
+	ConflictOverwrite = "overwrite" // archive's levels become new revisions of existing game's levels, profile is replaced
+	ConflictRename    = "rename"    // archive is imported as new game with free name, e.g. "labyrinth (2)"
+
+	ImportCreated     = "created"     // game didn't exist and is created
+	ImportSkipped     = "skipped"     // game exists and is kept
+	ImportOverwritten = "overwritten" // game exists and its levels get new revisions
+	ImportRenamed     = "renamed"     // game exists, so archive is imported under another name
+)
+
+// structure describe creator's game with all its levels in order, it's moved between environments as archive
+type GameArchiveType struct {
+	Creator string
+	Game    string
+	Profile string
+	Levels  []LevelType
+}
+
+// structure describe result of game import
+type ImportResultType struct {
+	Creator string
+	Game    string  // name of imported game, it differs from archive's one after rename
+	Status  string  // created, skipped, overwritten or renamed
+	Levels  []int64 // ids of imported levels in archive's order
+}
+
+// return true if conflict option is known one
+func IsConflictOption(conflict string) bool {
+	switch conflict {
+	case ConflictFail, ConflictSkip, ConflictOverwrite, ConflictRename:
+		return true
+	}
+
+	return false
+}
+
+// validate every level of archive with constraints of archive's profile, the same way as single level is validated.
+// violations of each level are marked by level number.
+// return nil or *ValidationErrorType
+func (obj *GameArchiveType) Validate(constraints config.ConstraintsType) (status error) {
+	var (
+		validation ValidationErrorType
+	)
+
+	for _, level := range obj.Levels {
+		var (
+			levelValidation *ValidationErrorType
+		)
+
+		level.Profile = obj.Profile
+
+		levelValidation, _ = level.Validate(constraints).(*ValidationErrorType)
+		if levelValidation == nil {
+			continue
+		}
+
+		for _, violation := range levelValidation.Violations {
+			violation.Level = new(int64)
+			*violation.Level = level.Level
+			violation.Message = fmt.Sprintf("level %d: %s", level.Level, violation.Message)
+
+			validation.Violations = append(validation.Violations, violation)
+		}
+	}
+
+	return validation.status()
+}
+
+// import archive as creator's game with all its levels in single transaction, existing game is handled by conflict
+// option. levels are analyzed again with constraints of archive's profile and saved as new revisions: the first one
+// of new level, the next one of level which overwritten game already has. levels which aren't in archive are kept.
+// return import result and nil, ErrConflict or ErrStorage
+func (obj *GameArchiveType) Import(db *sql.DB, conflict string, constraints config.ConstraintsType) (result ImportResultType, status error) {
+	var (
+		err error
+
+		tx *sql.Tx
+
+		creator CreatorType
+		game    GameType
+	)
+
+	tx, err = db.Begin()
+	if err != nil {
+		fmt.Println("[error] import game begin transaction:", err)
+		return result, ErrStorage
+	}
+
+	defer rollback(tx, "import game")
+
+	creator = CreatorType{TX: tx, Creator: obj.Creator}
+
+	game = GameType{TX: tx, Creator: obj.Creator, Game: obj.Game}
+
+	game.CreatorId = creator.addCreator()
+	if game.CreatorId < 1 {
+		return result, ErrStorage
+	}
+
+	result = ImportResultType{Creator: obj.Creator, Game: obj.Game, Status: ImportCreated, Levels: []int64{}}
+
+	game.Id = game.getGameId()
+	if game.Id < 0 {
+		return result, ErrStorage
+	}
+
+	if game.Id > 0 {
+		switch conflict {
+		case ConflictSkip:
+			// nothing is changed, so transaction is rolled back
+			result.Status = ImportSkipped
+			return result, nil
+
+		case ConflictOverwrite:
+			// levels are saved as usual uploads, so history of existing levels is kept in revisions
+			result.Status = ImportOverwritten
+
+		case ConflictRename:
+			result.Status = ImportRenamed
+
+			// the first free name among "game (2)", "game (3)" and so on
+			for number := 2; game.Id != 0; number++ {
+				game.Game = fmt.Sprintf("%s (%d)", obj.Game, number)
+
+				game.Id = game.getGameId()
+				if game.Id < 0 {
+					return result, ErrStorage
+				}
+			}
+
+			result.Game = game.Game
+
+			game.Id = game.addGame()
+			if game.Id < 1 {
+				return result, ErrStorage
+			}
+
+		default:
+			return result, ErrConflict
+		}
+	} else {
+		game.Id = game.addGame()
+		if game.Id < 1 {
+			return result, ErrStorage
+		}
+	}
+
+	if execStatement(tx, "import game profile", "UPDATE games SET profile = $1 WHERE (id = $2)", obj.Profile, game.Id) < 0 {
+		return result, ErrStorage
+	}
+
+	for _, archived := range obj.Levels {
+		var (
+			level LevelType
+		)
+
+		level = LevelType{TX: tx, CreatorId: game.CreatorId, GameId: game.Id, Profile: obj.Profile,
+			Creator: obj.Creator, Game: game.Game, Level: archived.Level, Data: archived.Data}
+
+		if level.saveLevel(constraints) < 1 {
+			return result, ErrStorage
+		}
+
+		result.Levels = append(result.Levels, level.Id)
+	}
+
+	status = commit(tx, "import game")
+
+	return result, status
+}
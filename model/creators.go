@@ -8,6 +8,7 @@ import (
 
 // structure describe creator
 type CreatorType struct {
+	DB      *sql.DB `json:"-"`
 	TX      *sql.Tx `json:"-"`
 	Creator string
 }
@@ -93,3 +94,186 @@ func (obj *CreatorType) getCreatorId() (id int64) {
 
 	return 0
 }
+
+// find creator and all creator's games. prepare sql statement and execute it in read transaction.
+// return list of games ordered by name and id for specific creator (0 if creator doesn't exist, -1 on error)
+func (obj *CreatorType) GetGames() (games []GameType, id int64) {
+	var (
+		err error
+
+		tx   *sql.Tx
+		stmt *sql.Stmt
+		row  *sql.Rows
+	)
+
+	tx, err = obj.DB.Begin()
+	if err != nil {
+		fmt.Println("[error] get games begin transaction:", err)
+		return nil, -1
+	}
+
+	defer func() {
+		if err = tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			fmt.Println("[error] get games rollback transaction:", err)
+		}
+	}()
+
+	obj.TX = tx
+
+	id = obj.getCreatorId()
+	if id < 1 {
+		return nil, id
+	}
+
+	stmt, err = obj.TX.Prepare("SELECT id, game, profile FROM games WHERE (creator_id = $1) ORDER BY game")
+	if err != nil {
+		fmt.Println("[error] get games prepare:", err)
+		return nil, -1
+	}
+
+	defer func() {
+		if err = stmt.Close(); err != nil {
+			fmt.Println("[error] get games clear stmt memory:", err)
+		}
+	}()
+
+	row, err = stmt.Query(id)
+	if err != nil {
+		fmt.Println("[error] get games query statement:", err)
+		return nil, -1
+	}
+
+	defer func() {
+		if err = row.Close(); err != nil {
+			fmt.Println("[error] get games clear row memory:", err)
+		}
+	}()
+
+	games = []GameType{}
+
+	for row.Next() {
+		var (
+			game GameType
+		)
+
+		err = row.Scan(&game.Id, &game.Game, &game.Profile)
+		if err != nil {
+			fmt.Println("[error] get games scan row:", err)
+			return nil, -1
+		}
+
+		game.CreatorId = id
+		game.Creator = obj.Creator
+		games = append(games, game)
+	}
+
+	return games, id
+}
+
+// delete creator with all creator's games and their levels in single transaction.
+// return nil, ErrNotFound or ErrStorage
+func (obj *CreatorType) Delete() (status error) {
+	var (
+		err error
+
+		tx *sql.Tx
+		id int64
+	)
+
+	tx, err = obj.DB.Begin()
+	if err != nil {
+		fmt.Println("[error] delete creator begin transaction:", err)
+		return ErrStorage
+	}
+
+	defer rollback(tx, "delete creator")
+
+	obj.TX = tx
+
+	id = obj.getCreatorId()
+	if id < 0 {
+		return ErrStorage
+	}
+
+	if id == 0 {
+		return ErrNotFound
+	}
+
+	// cascade from creator to games, levels and revisions the same way as foreign keys do
+	if execStatement(tx, "delete creator revisions", "DELETE FROM level_revisions WHERE level_id IN "+
+		"(SELECT l.id FROM levels l JOIN games g ON (g.id = l.game_id) WHERE (g.creator_id = $1))", id) < 0 {
+		return ErrStorage
+	}
+
+	if execStatement(tx, "delete creator levels", "DELETE FROM levels WHERE game_id IN (SELECT id FROM games WHERE (creator_id = $1))", id) < 0 {
+		return ErrStorage
+	}
+
+	if execStatement(tx, "delete creator games", "DELETE FROM games WHERE (creator_id = $1)", id) < 0 {
+		return ErrStorage
+	}
+
+	if execStatement(tx, "delete creator", "DELETE FROM creators WHERE (id = $1)", id) < 0 {
+		return ErrStorage
+	}
+
+	return commit(tx, "delete creator")
+}
+
+// rename creator in single transaction, new name must not be used by another creator, the current name is not a
+// conflict and changes nothing.
+// return nil, ErrNotFound, ErrConflict or ErrStorage
+func (obj *CreatorType) Rename(name string) (status error) {
+	var (
+		err error
+
+		tx *sql.Tx
+		id int64
+
+		another CreatorType
+	)
+
+	tx, err = obj.DB.Begin()
+	if err != nil {
+		fmt.Println("[error] rename creator begin transaction:", err)
+		return ErrStorage
+	}
+
+	defer rollback(tx, "rename creator")
+
+	obj.TX = tx
+
+	id = obj.getCreatorId()
+	if id < 0 {
+		return ErrStorage
+	}
+
+	if id == 0 {
+		return ErrNotFound
+	}
+
+	if name == obj.Creator {
+		return nil
+	}
+
+	// creator's name is unique
+	another = CreatorType{TX: tx, Creator: name}
+	switch another.getCreatorId() {
+	case -1:
+		return ErrStorage
+	case 0:
+	default:
+		return ErrConflict
+	}
+
+	if execStatement(tx, "rename creator", "UPDATE creators SET creator = $1 WHERE (id = $2)", name, id) < 0 {
+		return ErrStorage
+	}
+
+	status = commit(tx, "rename creator")
+	if status == nil {
+		obj.Creator = name
+	}
+
+	return status
+}
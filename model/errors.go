@@ -0,0 +1,76 @@
+package model
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound = errors.New("not found")      // entity doesn't exist
+	ErrConflict = errors.New("already exists") // entity with the same name or number already exists
+	ErrStorage  = errors.New("storage error")  // db failed, details are printed to log
+)
+
+// prepare sql statement and execute it within transaction. name is used in log messages.
+// return count of affected rows, -1 on error
+func execStatement(tx *sql.Tx, name string, query string, args ...interface{}) (affected int64) {
+	var (
+		err error
+
+		stmt   *sql.Stmt
+		result sql.Result
+	)
+
+	stmt, err = tx.Prepare(query)
+	if err != nil {
+		fmt.Printf("[error] %s prepare: %v\n", name, err)
+		return -1
+	}
+
+	defer func() {
+		if err = stmt.Close(); err != nil {
+			fmt.Printf("[error] %s clear stmt memory: %v\n", name, err)
+		}
+	}()
+
+	result, err = stmt.Exec(args...)
+	if err != nil {
+		fmt.Printf("[error] %s execute: %v\n", name, err)
+		return -1
+	}
+
+	affected, err = result.RowsAffected()
+	if err != nil {
+		fmt.Printf("[error] %s affected rows: %v\n", name, err)
+		return -1
+	}
+
+	return affected
+}
+
+// rollback transaction if it was not committed
+func rollback(tx *sql.Tx, name string) {
+	var (
+		err error
+	)
+
+	if err = tx.Rollback(); err != nil && err != sql.ErrTxDone {
+		fmt.Printf("[error] %s rollback transaction: %v\n", name, err)
+	}
+}
+
+// commit transaction.
+// return nil or ErrStorage
+func commit(tx *sql.Tx, name string) (status error) {
+	var (
+		err error
+	)
+
+	if err = tx.Commit(); err != nil {
+		fmt.Printf("[error] %s commit transaction: %v\n", name, err)
+		return ErrStorage
+	}
+
+	return nil
+}
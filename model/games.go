@@ -7,9 +7,13 @@ import (
 
 // structure describe game
 type GameType struct {
+	DB        *sql.DB `json:"-"`
 	TX        *sql.Tx `json:"-"`
+	Id        int64
 	CreatorId int64
+	Creator   string
 	Game      string
+	Profile   string
 }
 
 // add new game (if it needs). prepare sql statement and execute it.
@@ -47,7 +51,7 @@ func (obj *GameType) addGame() (id int64) {
 	return id
 }
 
-// prepare sql statement and execute it.
+// prepare sql statement and execute it, game's profile is filled too.
 // return id for specific creator's game
 func (obj *GameType) getGameId() (id int64) {
 	var (
@@ -57,7 +61,7 @@ func (obj *GameType) getGameId() (id int64) {
 		row  *sql.Rows
 	)
 
-	stmt, err = obj.TX.Prepare("SELECT id FROM games WHERE (creator_id = $1) and (game = $2)")
+	stmt, err = obj.TX.Prepare("SELECT id, profile FROM games WHERE (creator_id = $1) and (game = $2)")
 	if err != nil {
 		fmt.Println("[error] get game id prepare:", err)
 		return -1
@@ -82,7 +86,7 @@ func (obj *GameType) getGameId() (id int64) {
 	}()
 
 	for row.Next() {
-		err = row.Scan(&id)
+		err = row.Scan(&id, &obj.Profile)
 		if err != nil {
 			fmt.Println("[error] get game id scan row:", err)
 			return -1
@@ -93,3 +97,244 @@ func (obj *GameType) getGameId() (id int64) {
 
 	return 0
 }
+
+// find creator's game and all its levels. prepare sql statement and execute it in read transaction.
+// return list of levels ordered by level number and id for specific game (0 if game doesn't exist, -1 on error)
+func (obj *GameType) GetLevels() (levels []LevelType, id int64) {
+	var (
+		err error
+
+		tx   *sql.Tx
+		stmt *sql.Stmt
+		row  *sql.Rows
+
+		creator CreatorType
+	)
+
+	tx, err = obj.DB.Begin()
+	if err != nil {
+		fmt.Println("[error] get levels begin transaction:", err)
+		return nil, -1
+	}
+
+	defer func() {
+		if err = tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			fmt.Println("[error] get levels rollback transaction:", err)
+		}
+	}()
+
+	obj.TX = tx
+
+	// game is identified by creator's name, so find creator first
+	creator = CreatorType{TX: tx, Creator: obj.Creator}
+
+	obj.CreatorId = creator.getCreatorId()
+	if obj.CreatorId < 1 {
+		return nil, obj.CreatorId
+	}
+
+	obj.Id = obj.getGameId()
+	if obj.Id < 1 {
+		return nil, obj.Id
+	}
+
+	stmt, err = obj.TX.Prepare("SELECT " + levelColumns + " " + levelTables + " WHERE (l.game_id = $1) ORDER BY l.level")
+	if err != nil {
+		fmt.Println("[error] get levels prepare:", err)
+		return nil, -1
+	}
+
+	defer func() {
+		if err = stmt.Close(); err != nil {
+			fmt.Println("[error] get levels clear stmt memory:", err)
+		}
+	}()
+
+	row, err = stmt.Query(obj.Id)
+	if err != nil {
+		fmt.Println("[error] get levels query statement:", err)
+		return nil, -1
+	}
+
+	defer func() {
+		if err = row.Close(); err != nil {
+			fmt.Println("[error] get levels clear row memory:", err)
+		}
+	}()
+
+	levels = []LevelType{}
+
+	for row.Next() {
+		var (
+			level LevelType
+		)
+
+		err = level.scanLevel(row)
+		if err != nil {
+			fmt.Println("[error] get levels scan row:", err)
+			return nil, -1
+		}
+
+		levels = append(levels, level)
+	}
+
+	return levels, obj.Id
+}
+
+// delete creator's game with all its levels in single transaction.
+// return nil, ErrNotFound or ErrStorage
+func (obj *GameType) Delete() (status error) {
+	var (
+		err error
+
+		tx *sql.Tx
+	)
+
+	tx, err = obj.DB.Begin()
+	if err != nil {
+		fmt.Println("[error] delete game begin transaction:", err)
+		return ErrStorage
+	}
+
+	defer rollback(tx, "delete game")
+
+	obj.TX = tx
+
+	status = obj.findGame()
+	if status != nil {
+		return status
+	}
+
+	// cascade from game to levels and revisions the same way as foreign keys do
+	if execStatement(tx, "delete game revisions", "DELETE FROM level_revisions WHERE level_id IN (SELECT id FROM levels WHERE (game_id = $1))", obj.Id) < 0 {
+		return ErrStorage
+	}
+
+	if execStatement(tx, "delete game levels", "DELETE FROM levels WHERE (game_id = $1)", obj.Id) < 0 {
+		return ErrStorage
+	}
+
+	if execStatement(tx, "delete game", "DELETE FROM games WHERE (id = $1)", obj.Id) < 0 {
+		return ErrStorage
+	}
+
+	return commit(tx, "delete game")
+}
+
+// rename creator's game and assign constraints profile to it in single transaction, empty name keeps game's name,
+// nil profile keeps game's profile (empty one means default constraints). new name must not be used by another game
+// of the same creator, the current name is not a conflict.
+// return nil, ErrNotFound, ErrConflict or ErrStorage
+func (obj *GameType) Update(name string, profile *string) (status error) {
+	var (
+		err error
+
+		tx *sql.Tx
+
+		another GameType
+	)
+
+	tx, err = obj.DB.Begin()
+	if err != nil {
+		fmt.Println("[error] update game begin transaction:", err)
+		return ErrStorage
+	}
+
+	defer rollback(tx, "update game")
+
+	obj.TX = tx
+
+	status = obj.findGame()
+	if status != nil {
+		return status
+	}
+
+	if (name != "") && (name != obj.Game) {
+		// game's name is unique for creator
+		another = GameType{TX: tx, CreatorId: obj.CreatorId, Game: name}
+		switch another.getGameId() {
+		case -1:
+			return ErrStorage
+		case 0:
+		default:
+			return ErrConflict
+		}
+
+		if execStatement(tx, "rename game", "UPDATE games SET game = $1 WHERE (id = $2)", name, obj.Id) < 0 {
+			return ErrStorage
+		}
+	}
+
+	if profile != nil {
+		if execStatement(tx, "set game profile", "UPDATE games SET profile = $1 WHERE (id = $2)", *profile, obj.Id) < 0 {
+			return ErrStorage
+		}
+	}
+
+	status = commit(tx, "update game")
+	if status != nil {
+		return status
+	}
+
+	if name != "" {
+		obj.Game = name
+	}
+
+	if profile != nil {
+		obj.Profile = *profile
+	}
+
+	return nil
+}
+
+// find creator's game by names and fill its id and profile. prepare sql statement and execute it in read transaction.
+// return nil, ErrNotFound or ErrStorage
+func (obj *GameType) Load() (status error) {
+	var (
+		err error
+
+		tx *sql.Tx
+	)
+
+	tx, err = obj.DB.Begin()
+	if err != nil {
+		fmt.Println("[error] load game begin transaction:", err)
+		return ErrStorage
+	}
+
+	defer rollback(tx, "load game")
+
+	obj.TX = tx
+
+	return obj.findGame()
+}
+
+// find creator's and game's id by their names within current transaction.
+// return nil, ErrNotFound or ErrStorage
+func (obj *GameType) findGame() (status error) {
+	var (
+		creator CreatorType
+	)
+
+	creator = CreatorType{TX: obj.TX, Creator: obj.Creator}
+
+	obj.CreatorId = creator.getCreatorId()
+	if obj.CreatorId < 0 {
+		return ErrStorage
+	}
+
+	if obj.CreatorId == 0 {
+		return ErrNotFound
+	}
+
+	obj.Id = obj.getGameId()
+	if obj.Id < 0 {
+		return ErrStorage
+	}
+
+	if obj.Id == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
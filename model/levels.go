@@ -5,65 +5,165 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"greenjade/analyze"
+	"greenjade/codec"
 	"greenjade/config"
 )
 
+const (
+	// columns and tables to fetch stored level together with its game and creator
+	levelColumns = "l.id, l.level, l.data, l.msp_status, l.msp_length, l.msp_path, l.traps, l.open_tiles, l.reachable_area, g.id, g.game, g.profile, c.id, c.creator"
+	levelTables  = "FROM levels l JOIN games g ON (g.id = l.game_id) JOIN creators c ON (c.id = g.creator_id)"
+)
+
 // structure describe input data about level and processed data
 type LevelType struct {
 	DB        *sql.DB `json:"-"`
 	TX        *sql.Tx `json:"-"`
 	CreatorId int64   `json:"-"`
 	GameId    int64   `json:"-"`
-	JsonData  []byte  `json:"-"`
+	Profile   string  `json:"-"`
+	RawData   []byte  `json:"-"`
+	JsonPath  []byte  `json:"-"`
+	Id        int64
 	Creator   string
 	Game      string
 	Level     int64
 	Data      [][]int
+	Analysis  analyze.ResultType
 }
 
-// apply to level data constraints. constraints specify in config file section Constraints.
-// return nil or error object
+// apply to level data constraints. constraints specify in config file section Constraints, constraints profile of
+// level's game (if it's set) is used instead of default constraints. all violations are collected, not only the first one.
+// return nil or *ValidationErrorType object
 func (obj *LevelType) Validate(constraints config.ConstraintsType) (status error) {
+	var (
+		validation ValidationErrorType
+	)
+
+	constraints = obj.ProfileConstraints(constraints)
+
+	// there is nothing to check in level without lines
+	if !obj.validateShape(constraints, &validation) {
+		return validation.status()
+	}
+
+	for row, line := range obj.Data {
+		// in each column must be only valid integer marks
+		for column, value := range line {
+			if (value < constraints.Point.Min) || (value > constraints.Point.Max) {
+				validation.add(ViolationInvalidPoint, row, column, fmt.Sprintf("level must contains only [%d..%d] values, broken value %d in point [%d,%d]", constraints.Point.Min, constraints.Point.Max, value, row+1, column+1))
+				continue
+			}
+
+			// profile may allow only some of values from range
+			if (len(constraints.Point.Allowed) > 0) && !allowedPoint(constraints.Point.Allowed, value) {
+				validation.add(ViolationInvalidPoint, row, column, fmt.Sprintf("level must contains only %v values, broken value %d in point [%d,%d]", constraints.Point.Allowed, value, row+1, column+1))
+			}
+		}
+	}
+
+	// structural rules are checked only if they are enabled
+	obj.validateRules(constraints, &validation)
+
+	// hero must be able to reach exit, if it's required. there is no sense to analyze level which is broken already
+	if constraints.RequireSolvable && (len(validation.Violations) == 0) {
+		_, status = analyze.Analyze(obj.Data, constraints)
+		if status != nil {
+			validation.add(status.(analyze.StatusType).Code(), -1, -1, fmt.Sprintf("level can't be solved: %s", status.Error()))
+		}
+	}
+
+	return validation.status()
+}
+
+// apply to level data only dimension constraints and check that level is rectangular, it's enough to analyze level
+// without storing it. constraints must be already chosen by profile of level's game, see ProfileConstraints.
+// return nil or *ValidationErrorType object
+func (obj *LevelType) ValidateShape(constraints config.ConstraintsType) (status error) {
+	var (
+		validation ValidationErrorType
+	)
+
+	obj.validateShape(constraints, &validation)
+
+	return validation.status()
+}
+
+// check that level isn't empty, count of lines and length of each line fit dimension constraints and level is
+// rectangular. violations are added to validation.
+// return false if level has no lines, so there is nothing to check further
+func (obj *LevelType) validateShape(constraints config.ConstraintsType, validation *ValidationErrorType) bool {
 	var (
 		lenLine int
 	)
 
-	// check single line length
-	if len(obj.Data) > constraints.Dimension.Max {
-		return errors.New(fmt.Sprintf("max count of lines cannot be more than %d", constraints.Dimension.Max))
+	if len(obj.Data) == 0 {
+		validation.add(ViolationEmptyLevel, -1, -1, "level must have at least one line")
+		return false
+	}
+
+	// check count of lines (height of level)
+	if len(obj.Data) < constraints.Dimension.Height.Min {
+		validation.add(ViolationTooFewLines, -1, -1, fmt.Sprintf("min count of lines cannot be less than %d", constraints.Dimension.Height.Min))
+	}
+
+	if len(obj.Data) > constraints.Dimension.Height.Max {
+		validation.add(ViolationTooManyLines, -1, -1, fmt.Sprintf("max count of lines cannot be more than %d", constraints.Dimension.Height.Max))
 	}
 
 	// init level's length by length of first line
 	lenLine = len(obj.Data[0])
 
 	for row, line := range obj.Data {
-		// check single line length
-		if lenLine > constraints.Dimension.Max {
-			return errors.New(fmt.Sprintf("max line's length cannot be more than %d, broken line %d", constraints.Dimension.Max, row+1))
+		// check single line length (width of level)
+		if len(line) < constraints.Dimension.Width.Min {
+			validation.add(ViolationLineTooShort, row, -1, fmt.Sprintf("min line's length cannot be less than %d, broken line %d", constraints.Dimension.Width.Min, row+1))
 		}
 
-		// if length current line does not equal to previous line length, than validation failed
-		if lenLine != len(line) {
-			return errors.New(fmt.Sprintf("level must be rectangular, broken line is %d", row+1))
+		if len(line) > constraints.Dimension.Width.Max {
+			validation.add(ViolationLineTooLong, row, -1, fmt.Sprintf("max line's length cannot be more than %d, broken line %d", constraints.Dimension.Width.Max, row+1))
 		}
 
-		// in each column must be only valid integer marks
-		for column, value := range line {
-			if (value < constraints.Point.Min) || (value > constraints.Point.Max) {
-				return errors.New(fmt.Sprintf("level must contains only [0..4] values, broken value %d in point [%d,%d]", value, row+1, column+1))
-			}
+		// if length current line does not equal to first line length, than validation failed
+		if lenLine != len(line) {
+			validation.add(ViolationNotRectangular, row, -1, fmt.Sprintf("level must be rectangular, broken line is %d", row+1))
 		}
+	}
 
-		// store length of current line to compare with the next line
-		lenLine = len(line)
+	return true
+}
+
+// choose constraints of level's game profile, profile which was removed from config falls back to default constraints.
+// return constraints for level
+func (obj *LevelType) ProfileConstraints(constraints config.ConstraintsType) (profile config.ConstraintsType) {
+	var (
+		ok bool
+	)
+
+	profile, ok = constraints.Profile(obj.Profile)
+	if !ok {
+		fmt.Println("[warning] unknown constraints profile", obj.Profile, "of game", obj.Game, "default constraints are used")
+	}
+
+	return profile
+}
+
+// return true if point value is in list of allowed values
+func allowedPoint(allowed []int, value int) bool {
+	for _, point := range allowed {
+		if point == value {
+			return true
+		}
 	}
 
-	return status
+	return false
 }
 
-// all needed actions to store level: find or create creator and game, delete previous level data and store new data.
+// all needed actions to store level: find or create creator and game, store new level data (or update previous one)
+// together with analysis results and add new revision of level.
 // return id new db's record
-func (obj *LevelType) Store() (levelId int64) {
+func (obj *LevelType) Store(constraints config.ConstraintsType) (levelId int64) {
 	var (
 		err error
 
@@ -108,67 +208,180 @@ func (obj *LevelType) Store() (levelId int64) {
 		return -1
 	}
 
-	// add creator and game id to level structure, drop previous level data
+	// add creator and game id to level structure, level is analyzed with game's constraints profile
 	obj.CreatorId = creatorId
 	obj.GameId = gameId
+	obj.Profile = game.Profile
 
-	if !obj.dropLevels() {
-		fmt.Println("[error] can't drop levels")
+	levelId = obj.saveLevel(constraints)
+	if levelId < 1 {
 		return -1
 	}
 
-	// before storing convert to json level data and add it
-	obj.JsonData, err = json.Marshal(obj.Data)
+	// commit common transaction
+	err = tx.Commit()
 	if err != nil {
-		fmt.Println("[error] can't convert level's data to json")
+		fmt.Println("[error] store commit transaction:", err)
 		return -1
 	}
 
-	levelId = obj.addLevels()
+	return levelId
+}
+
+// analyze levels which were stored before analysis columns existed, migration leaves their msp status empty.
+// all levels are updated in single transaction, data is converted to binary format on the way.
+// return count of analyzed levels, -1 on error
+func BackfillAnalysis(db *sql.DB, constraints config.ConstraintsType) (count int) {
+	var (
+		err error
+
+		tx     *sql.Tx
+		rows   *sql.Rows
+		levels []LevelType
+	)
+
+	tx, err = db.Begin()
+	if err != nil {
+		fmt.Println("[error] backfill analysis begin transaction:", err)
+		return -1
+	}
+
+	defer rollback(tx, "backfill analysis")
+
+	rows, err = tx.Query("SELECT l.id, l.data, g.profile FROM levels l JOIN games g ON (g.id = l.game_id) WHERE (l.msp_status = '')")
+	if err != nil {
+		fmt.Println("[error] backfill analysis select levels:", err)
+		return -1
+	}
+
+	for rows.Next() {
+		var (
+			level LevelType
+		)
+
+		err = rows.Scan(&level.Id, &level.RawData, &level.Profile)
+		if err != nil {
+			fmt.Println("[error] backfill analysis scan row:", err)
+			_ = rows.Close()
+			return -1
+		}
+
+		levels = append(levels, level)
+	}
+
+	// all rows are read before updates, sqlite can't update table while it's selected
+	err = rows.Close()
+	if err != nil {
+		fmt.Println("[error] backfill analysis close rows:", err)
+		return -1
+	}
+
+	for _, level := range levels {
+		level.TX = tx
+
+		level.Data, err = decodeData(level.RawData)
+		if err != nil {
+			fmt.Printf("[error] backfill analysis decode level %d: %v\n", level.Id, err)
+			return -1
+		}
+
+		if !level.prepareLevel(constraints) || (level.updateLevel(level.Id) < 1) {
+			return -1
+		}
+	}
+
+	if commit(tx, "backfill analysis") != nil {
+		return -1
+	}
+
+	return len(levels)
+}
+
+// analyze level, store level data with analysis results within current transaction and add new revision.
+// level with the same game and number is updated, previous data is kept in revisions.
+// return id for level record
+func (obj *LevelType) saveLevel(constraints config.ConstraintsType) (levelId int64) {
+	var (
+		revision RevisionType
+	)
+
+	if !obj.prepareLevel(constraints) {
+		return -1
+	}
+
+	levelId = obj.getLevelId()
+	switch {
+	case levelId < 0:
+		return -1
+	case levelId == 0:
+		levelId = obj.addLevels()
+	default:
+		levelId = obj.updateLevel(levelId)
+	}
+
 	if levelId < 1 {
 		fmt.Println("[error] can't add level")
 		return -1
 	}
 
-	// commit common transaction
-	err = tx.Commit()
-	if err != nil {
-		fmt.Println("[error] store commit transaction:", err)
+	obj.Id = levelId
+
+	// every upload is new revision of level
+	revision = RevisionType{TX: obj.TX, LevelId: levelId, RawData: obj.RawData}
+	if revision.addRevision() < 1 {
+		fmt.Println("[error] can't add level revision")
 		return -1
 	}
 
 	return levelId
 }
 
-// drop previous level data. prepare sql statement and execute it.
-func (obj *LevelType) dropLevels() bool {
+// analyze level and convert level data to binary format and msp to json before storing.
+// unsolvable level gets negative msp length.
+// return true if level is ready to store
+func (obj *LevelType) prepareLevel(constraints config.ConstraintsType) bool {
 	var (
 		err error
-
-		stmt *sql.Stmt
 	)
 
-	stmt, err = obj.TX.Prepare("DELETE FROM levels WHERE (game_id = $1) and (level = $2)")
+	obj.RawData, err = encodeData(obj.Data)
 	if err != nil {
-		fmt.Println("[error] drop levels prepare:", err)
+		fmt.Println("[error] can't convert level's data to binary format:", err)
 		return false
 	}
 
-	defer func() {
-		if err = stmt.Close(); err != nil {
-			fmt.Println("[error] drop levels clear stmt memory:", err)
-		}
-	}()
+	// analyze level to store its difficulty
+	obj.Analysis, _ = analyze.Analyze(obj.Data, obj.ProfileConstraints(constraints))
+	if obj.Analysis.Status != analyze.StatusSolved {
+		obj.Analysis.Length = -1
+	}
 
-	_, err = stmt.Exec(obj.GameId, obj.Level)
+	obj.JsonPath, err = json.Marshal(obj.Analysis.Path)
 	if err != nil {
-		fmt.Println("[error] drop levels execute:", err)
+		fmt.Println("[error] can't convert level's msp to json")
 		return false
 	}
 
 	return true
 }
 
+// update level data and analysis results. prepare sql statement and execute it.
+// return id for updated record
+func (obj *LevelType) updateLevel(id int64) (levelId int64) {
+	var (
+		affected int64
+	)
+
+	affected = execStatement(obj.TX, "update level", "UPDATE levels SET data = $1, msp_status = $2, msp_length = $3, msp_path = $4, traps = $5, open_tiles = $6, reachable_area = $7 WHERE (id = $8)",
+		obj.RawData, obj.Analysis.Status.Code(), obj.Analysis.Length, obj.JsonPath,
+		obj.Analysis.Traps, obj.Analysis.OpenTiles, obj.Analysis.ReachableArea, id)
+	if affected < 1 {
+		return -1
+	}
+
+	return id
+}
+
 // add actual level data. prepare sql statement and execute it.
 // return id for record with new data in db
 func (obj *LevelType) addLevels() (id int64) {
@@ -178,7 +391,7 @@ func (obj *LevelType) addLevels() (id int64) {
 		stmt *sql.Stmt
 	)
 
-	stmt, err = obj.TX.Prepare("INSERT INTO levels (game_id, level, data) VALUES ($1, $2, $3)")
+	stmt, err = obj.TX.Prepare("INSERT INTO levels (game_id, level, data, msp_status, msp_length, msp_path, traps, open_tiles, reachable_area) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)")
 	if err != nil {
 		fmt.Println("[error] add levels prepare:", err)
 		return -1
@@ -190,7 +403,8 @@ func (obj *LevelType) addLevels() (id int64) {
 		}
 	}()
 
-	_, err = stmt.Exec(obj.GameId, obj.Level, obj.JsonData)
+	_, err = stmt.Exec(obj.GameId, obj.Level, obj.RawData, obj.Analysis.Status.Code(), obj.Analysis.Length, obj.JsonPath,
+		obj.Analysis.Traps, obj.Analysis.OpenTiles, obj.Analysis.ReachableArea)
 	if err != nil {
 		fmt.Println("[error] add levels execute:", err)
 		return -1
@@ -245,3 +459,197 @@ func (obj *LevelType) getLevelId() (id int64) {
 
 	return 0
 }
+
+// load stored level by its id. prepare sql statement and execute it.
+// return id for loaded level (0 if level doesn't exist, -1 on error)
+func (obj *LevelType) LoadById(id int64) (levelId int64) {
+	return obj.loadLevel("SELECT "+levelColumns+" "+levelTables+" WHERE (l.id = $1)", id)
+}
+
+// load stored level by creator's name, game's name and level number which are set in level structure.
+// prepare sql statement and execute it.
+// return id for loaded level (0 if level doesn't exist, -1 on error)
+func (obj *LevelType) LoadByNumber() (levelId int64) {
+	return obj.loadLevel("SELECT "+levelColumns+" "+levelTables+" WHERE (c.creator = $1) and (g.game = $2) and (l.level = $3)",
+		obj.Creator, obj.Game, obj.Level)
+}
+
+// prepare sql statement which selects single level, execute it and fill level structure.
+// return id for loaded level (0 if level doesn't exist, -1 on error)
+func (obj *LevelType) loadLevel(query string, args ...interface{}) (levelId int64) {
+	var (
+		err error
+
+		stmt *sql.Stmt
+		row  *sql.Rows
+	)
+
+	stmt, err = obj.DB.Prepare(query)
+	if err != nil {
+		fmt.Println("[error] load level prepare:", err)
+		return -1
+	}
+
+	defer func() {
+		if err = stmt.Close(); err != nil {
+			fmt.Println("[error] load level clear stmt memory:", err)
+		}
+	}()
+
+	row, err = stmt.Query(args...)
+	if err != nil {
+		fmt.Println("[error] load level query statement:", err)
+		return -1
+	}
+
+	defer func() {
+		if err = row.Close(); err != nil {
+			fmt.Println("[error] load level clear row memory:", err)
+		}
+	}()
+
+	for row.Next() {
+		err = obj.scanLevel(row)
+		if err != nil {
+			fmt.Println("[error] load level scan row:", err)
+			return -1
+		}
+
+		return obj.Id
+	}
+
+	return 0
+}
+
+// scan row selected with levelColumns into level structure and decode level data and json path
+func (obj *LevelType) scanLevel(row *sql.Rows) (err error) {
+	var (
+		status string
+		ok     bool
+	)
+
+	err = row.Scan(&obj.Id, &obj.Level, &obj.RawData, &status, &obj.Analysis.Length, &obj.JsonPath,
+		&obj.Analysis.Traps, &obj.Analysis.OpenTiles, &obj.Analysis.ReachableArea,
+		&obj.GameId, &obj.Game, &obj.Profile, &obj.CreatorId, &obj.Creator)
+	if err != nil {
+		return err
+	}
+
+	obj.Analysis.Status, ok = analyze.ParseStatus(status)
+	if !ok {
+		return errors.New(fmt.Sprintf("unknown msp status %q", status))
+	}
+
+	obj.Data, err = decodeData(obj.RawData)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(obj.JsonPath, &obj.Analysis.Path)
+}
+
+// convert level data into compact binary format of codec package, the same format is used for levels and revisions
+// return stored data
+func encodeData(data [][]int) (raw []byte, err error) {
+	return codec.BinaryType{}.Encode(data)
+}
+
+// convert stored level data back, data which was stored before binary format is json.
+// return level data
+func decodeData(raw []byte) (data [][]int, err error) {
+	if codec.IsBinary(raw) {
+		return codec.BinaryType{}.Decode(raw)
+	}
+
+	err = json.Unmarshal(raw, &data)
+
+	return data, err
+}
+
+// delete level by id which is set in level structure together with its revisions, in single transaction.
+// return nil, ErrNotFound or ErrStorage
+func (obj *LevelType) Delete() (status error) {
+	var (
+		err error
+
+		tx       *sql.Tx
+		affected int64
+	)
+
+	tx, err = obj.DB.Begin()
+	if err != nil {
+		fmt.Println("[error] delete level begin transaction:", err)
+		return ErrStorage
+	}
+
+	defer rollback(tx, "delete level")
+
+	// cascade from level to its revisions the same way as foreign key does
+	if execStatement(tx, "delete level revisions", "DELETE FROM level_revisions WHERE (level_id = $1)", obj.Id) < 0 {
+		return ErrStorage
+	}
+
+	affected = execStatement(tx, "delete level", "DELETE FROM levels WHERE (id = $1)", obj.Id)
+	if affected < 0 {
+		return ErrStorage
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return commit(tx, "delete level")
+}
+
+// change number of level which id is set in level structure, in single transaction.
+// new number must not be used by another level of the same game.
+// return nil, ErrNotFound, ErrConflict or ErrStorage
+func (obj *LevelType) Renumber(number int64) (status error) {
+	var (
+		err error
+
+		tx  *sql.Tx
+		row *sql.Row
+	)
+
+	tx, err = obj.DB.Begin()
+	if err != nil {
+		fmt.Println("[error] renumber level begin transaction:", err)
+		return ErrStorage
+	}
+
+	defer rollback(tx, "renumber level")
+
+	obj.TX = tx
+
+	// find level's game
+	row = tx.QueryRow("SELECT game_id FROM levels WHERE (id = $1)", obj.Id)
+
+	err = row.Scan(&obj.GameId)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+
+	if err != nil {
+		fmt.Println("[error] renumber level scan row:", err)
+		return ErrStorage
+	}
+
+	// level's number is unique for game
+	obj.Level = number
+	switch obj.getLevelId() {
+	case -1:
+		return ErrStorage
+	case 0:
+	case obj.Id:
+		return nil
+	default:
+		return ErrConflict
+	}
+
+	if execStatement(tx, "renumber level", "UPDATE levels SET level = $1 WHERE (id = $2)", number, obj.Id) < 0 {
+		return ErrStorage
+	}
+
+	return commit(tx, "renumber level")
+}
@@ -1,12 +1,17 @@
 package model
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"greenjade/analyze"
 	"greenjade/config"
+	"greenjade/database"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -49,7 +54,11 @@ func TestValidateDataAllOk(t *testing.T) {
 		level LevelType
 	)
 
-	cfg = config.BuildConfig("../")
+	cfg, err = config.BuildConfig("../")
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
 
 	level, err = fetchJsonData(t, "../testdata/data_all_ok_2_msp_12.json")
 	if err != nil {
@@ -71,7 +80,11 @@ func TestValidateDataPointValueLessThanMin(t *testing.T) {
 		level LevelType
 	)
 
-	cfg = config.BuildConfig("../")
+	cfg, err = config.BuildConfig("../")
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
 
 	level, err = fetchJsonData(t, "../testdata/data_point_value_less_than_min.json")
 	if err != nil {
@@ -93,7 +106,11 @@ func TestValidateDataPointValueGreaterThanMax(t *testing.T) {
 		level LevelType
 	)
 
-	cfg = config.BuildConfig("../")
+	cfg, err = config.BuildConfig("../")
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
 
 	level, err = fetchJsonData(t, "../testdata/data_point_value_greater_than_max.json")
 	if err != nil {
@@ -115,7 +132,11 @@ func TestValidateDataNotRectangle(t *testing.T) {
 		level LevelType
 	)
 
-	cfg = config.BuildConfig("../")
+	cfg, err = config.BuildConfig("../")
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
 
 	level, err = fetchJsonData(t, "../testdata/data_not_rectangle.json")
 	if err != nil {
@@ -137,7 +158,11 @@ func TestValidateDataTooManyX(t *testing.T) {
 		level LevelType
 	)
 
-	cfg = config.BuildConfig("../")
+	cfg, err = config.BuildConfig("../")
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
 
 	level, err = fetchJsonData(t, "../testdata/data_too_many_x.json")
 	if err != nil {
@@ -159,7 +184,11 @@ func TestValidateDataTooManyY(t *testing.T) {
 		level LevelType
 	)
 
-	cfg = config.BuildConfig("../")
+	cfg, err = config.BuildConfig("../")
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
 
 	level, err = fetchJsonData(t, "../testdata/data_too_many_y.json")
 	if err != nil {
@@ -172,3 +201,236 @@ func TestValidateDataTooManyY(t *testing.T) {
 		t.Error("unexpected success")
 	}
 }
+
+func TestValidateDataUnsolvable(t *testing.T) {
+	var (
+		err, status error
+
+		cfg   *config.ConfType
+		level LevelType
+	)
+
+	cfg = &config.ConfType{}
+	cfg.SetDefaults()
+
+	level, err = fetchJsonData(t, "../testdata/data_unsolvable.json")
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	// without requirement unsolvable level is still valid
+	cfg.Constraints.RequireSolvable = false
+
+	status = level.Validate(cfg.Constraints)
+	if status != nil {
+		t.Error(status.Error())
+	}
+
+	cfg.Constraints.RequireSolvable = true
+
+	status = level.Validate(cfg.Constraints)
+	if status == nil {
+		t.Error("unexpected success")
+	}
+}
+
+func TestValidateDataAllViolations(t *testing.T) {
+	var (
+		status error
+
+		cfg        *config.ConfType
+		level      LevelType
+		validation *ValidationErrorType
+		ok         bool
+	)
+
+	cfg = &config.ConfType{}
+	cfg.SetDefaults()
+
+	level.Data = [][]int{
+		{1, 1, 0, 1},
+		{1, -1, 4, 1, 1},
+		{1, 9, 1, 1},
+	}
+
+	status = level.Validate(cfg.Constraints)
+	if status == nil {
+		t.Error("unexpected success")
+		t.FailNow()
+	}
+
+	validation, ok = status.(*ValidationErrorType)
+	if !ok {
+		t.Errorf("unexpected error type %T", status)
+		t.FailNow()
+	}
+
+	if len(validation.Violations) != 3 {
+		t.Errorf("expected 3 violations, got %d: %s", len(validation.Violations), validation.Error())
+		t.FailNow()
+	}
+
+	if (validation.Violations[0].Code != ViolationNotRectangular) || (*validation.Violations[0].Row != 1) {
+		t.Errorf("unexpected first violation: %v", validation.Violations[0])
+	}
+
+	if (validation.Violations[2].Code != ViolationInvalidPoint) || (*validation.Violations[2].Row != 2) || (*validation.Violations[2].Column != 1) {
+		t.Errorf("unexpected last violation: %v", validation.Violations[2])
+	}
+}
+
+func TestValidateDataProfile(t *testing.T) {
+	var (
+		status error
+
+		cfg        *config.ConfType
+		profile    config.ConstraintsType
+		level      LevelType
+		validation *ValidationErrorType
+		ok         bool
+	)
+
+	cfg = &config.ConfType{}
+	cfg.SetDefaults()
+
+	// puzzle games have no traps
+	profile = cfg.Constraints
+	profile.Point.Allowed = []int{0, 1, 4}
+	cfg.Constraints.Profiles = map[string]config.ConstraintsType{"puzzle": profile}
+
+	level.Data = [][]int{
+		{1, 0, 1},
+		{1, 4, 2},
+		{1, 1, 1},
+	}
+
+	status = level.Validate(cfg.Constraints)
+	if status != nil {
+		t.Errorf("unexpected violations with default constraints: %s", status.Error())
+	}
+
+	level.Profile = "puzzle"
+
+	status = level.Validate(cfg.Constraints)
+	validation, ok = status.(*ValidationErrorType)
+	if !ok || (len(validation.Violations) != 1) || (*validation.Violations[0].Column != 2) {
+		t.Errorf("expected single violation of profile, got %v", status)
+	}
+
+	// profile which was removed from config falls back to default constraints
+	level.Profile = "removed"
+
+	if len(level.ProfileConstraints(cfg.Constraints).Point.Allowed) != 0 {
+		t.Error("expected default constraints for unknown profile")
+	}
+
+	level.Profile = "puzzle"
+
+	if len(level.ProfileConstraints(cfg.Constraints).Point.Allowed) != 3 {
+		t.Error("expected constraints of puzzle profile")
+	}
+}
+
+func TestValidateDataDimensions(t *testing.T) {
+	var (
+		cfg   *config.ConfType
+		codes []string
+	)
+
+	cfg = &config.ConfType{}
+	cfg.SetDefaults()
+
+	cfg.Constraints.Dimension.Width.Min = 3
+	cfg.Constraints.Dimension.Width.Max = 4
+	cfg.Constraints.Dimension.Height.Min = 2
+	cfg.Constraints.Dimension.Height.Max = 3
+
+	levels := []struct {
+		data  [][]int
+		codes []string
+	}{
+		{nil, []string{ViolationEmptyLevel}},
+		{[][]int{}, []string{ViolationEmptyLevel}},
+		{[][]int{{}}, []string{ViolationTooFewLines, ViolationLineTooShort}},
+		{[][]int{{1, 0, 1}, {}}, []string{ViolationLineTooShort, ViolationNotRectangular}},
+		{[][]int{{1, 4, 1, 1, 1}, {1, 0, 1, 1, 1}}, []string{ViolationLineTooLong, ViolationLineTooLong}},
+		{[][]int{{1, 4, 1}, {1, 0, 1}, {1, 0, 1}, {1, 0, 1}}, []string{ViolationTooManyLines}},
+		{[][]int{{1, 4, 1}, {1, 0, 1}}, nil},
+	}
+
+	for i, level := range levels {
+		var (
+			status error
+
+			validation *ValidationErrorType
+		)
+
+		status = (&LevelType{Data: level.data}).Validate(cfg.Constraints)
+
+		codes = nil
+		if validation, _ = status.(*ValidationErrorType); validation != nil {
+			for _, violation := range validation.Violations {
+				codes = append(codes, violation.Code)
+			}
+		}
+
+		if strings.Join(codes, ",") != strings.Join(level.codes, ",") {
+			t.Errorf("level %d: expected violations %v, got %v", i, level.codes, codes)
+		}
+	}
+}
+
+func TestBackfillAnalysis(t *testing.T) {
+	var (
+		err error
+
+		cfg   *config.ConfType
+		db    *sql.DB
+		level LevelType
+		count int
+	)
+
+	cfg = &config.ConfType{}
+	cfg.SetDefaults()
+
+	db = database.OpenSQLite(filepath.Join(t.TempDir(), "levels.db"))
+	if db == nil {
+		t.Fatal("can't open sqlite db")
+	}
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// level as migration leaves it: json data and empty analysis
+	for _, query := range []string{
+		"INSERT INTO creators (creator) VALUES ('creator')",
+		"INSERT INTO games (creator_id, game) VALUES (1, 'game')",
+		"INSERT INTO levels (game_id, level, data) VALUES (1, 1, '[[1,1,1],[1,4,1],[1,0,1],[1,5,1],[1,1,1]]')",
+	} {
+		_, err = db.Exec(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count = BackfillAnalysis(db, cfg.Constraints)
+	if count != 1 {
+		t.Fatalf("expected 1 analyzed level, got %d", count)
+	}
+
+	level = LevelType{DB: db}
+	if level.LoadById(1) != 1 {
+		t.Fatal("can't load analyzed level")
+	}
+
+	if (level.Analysis.Status != analyze.StatusSolved) || (level.Analysis.Length != 2) || (level.Analysis.ReachableArea != 3) {
+		t.Errorf("unexpected analysis %+v", level.Analysis)
+	}
+
+	count = BackfillAnalysis(db, cfg.Constraints)
+	if count != 0 {
+		t.Errorf("expected no levels to analyze, got %d", count)
+	}
+}
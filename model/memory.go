@@ -0,0 +1,610 @@
+package model
+
+import (
+	"fmt"
+	"greenjade/config"
+	"sort"
+	"sync"
+	"time"
+)
+
+// repository which keeps data in process memory, it's safe for concurrent use.
+// entities are linked by ids the same way as db tables are.
+type MemoryType struct {
+	mutex sync.RWMutex
+
+	creators  map[int64]string
+	games     map[int64]GameType
+	levels    map[int64]LevelType
+	revisions map[int64][]RevisionType
+
+	creatorSeq, gameSeq, levelSeq, revisionSeq int64
+}
+
+// create empty in-memory repository.
+// return repository instance
+func NewMemoryStorage() (repo *MemoryType) {
+	return &MemoryType{
+		creators:  make(map[int64]string),
+		games:     make(map[int64]GameType),
+		levels:    make(map[int64]LevelType),
+		revisions: make(map[int64][]RevisionType),
+	}
+}
+
+func (repo *MemoryType) StoreLevel(level *LevelType, constraints config.ConstraintsType) (levelId int64) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	// find or create creator and game
+	level.CreatorId = repo.findCreator(level.Creator)
+	if level.CreatorId == 0 {
+		repo.creatorSeq++
+		level.CreatorId = repo.creatorSeq
+		repo.creators[level.CreatorId] = level.Creator
+	}
+
+	level.GameId = repo.findGame(level.CreatorId, level.Game)
+	if level.GameId == 0 {
+		repo.gameSeq++
+		level.GameId = repo.gameSeq
+		repo.games[level.GameId] = GameType{Id: level.GameId, CreatorId: level.CreatorId, Game: level.Game}
+	}
+
+	// level is analyzed with game's constraints profile
+	level.Profile = repo.games[level.GameId].Profile
+
+	return repo.saveLevel(level, constraints)
+}
+
+func (repo *MemoryType) LoadLevelById(level *LevelType, id int64) (levelId int64) {
+	var (
+		stored LevelType
+		ok     bool
+	)
+
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
+	stored, ok = repo.levels[id]
+	if !ok {
+		return 0
+	}
+
+	repo.fillLevel(level, stored)
+
+	return level.Id
+}
+
+func (repo *MemoryType) LoadLevelByNumber(level *LevelType) (levelId int64) {
+	var (
+		creatorId, gameId int64
+	)
+
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
+	creatorId = repo.findCreator(level.Creator)
+	if creatorId == 0 {
+		return 0
+	}
+
+	gameId = repo.findGame(creatorId, level.Game)
+	if gameId == 0 {
+		return 0
+	}
+
+	levelId = repo.findLevel(gameId, level.Level)
+	if levelId == 0 {
+		return 0
+	}
+
+	repo.fillLevel(level, repo.levels[levelId])
+
+	return levelId
+}
+
+func (repo *MemoryType) DeleteLevel(level *LevelType) (status error) {
+	var (
+		ok bool
+	)
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	_, ok = repo.levels[level.Id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	delete(repo.revisions, level.Id)
+	delete(repo.levels, level.Id)
+
+	return nil
+}
+
+func (repo *MemoryType) RenumberLevel(level *LevelType, number int64) (status error) {
+	var (
+		stored  LevelType
+		ok      bool
+		another int64
+	)
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	stored, ok = repo.levels[level.Id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	// level's number is unique for game
+	another = repo.findLevel(stored.GameId, number)
+	if another == level.Id {
+		return nil
+	}
+
+	if another != 0 {
+		return ErrConflict
+	}
+
+	stored.Level = number
+	repo.levels[level.Id] = stored
+
+	return nil
+}
+
+func (repo *MemoryType) GetRevisions(level *LevelType) (revisions []RevisionType, levelId int64) {
+	var (
+		stored LevelType
+		ok     bool
+	)
+
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
+	stored, ok = repo.levels[level.Id]
+	if !ok {
+		return nil, 0
+	}
+
+	repo.fillLevel(level, stored)
+
+	// revisions are listed without level data
+	revisions = []RevisionType{}
+	for _, revision := range repo.revisions[level.Id] {
+		revision.Data = nil
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, level.Id
+}
+
+func (repo *MemoryType) LoadRevision(revision *RevisionType) (id int64) {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
+	for _, stored := range repo.revisions[revision.LevelId] {
+		if stored.Revision == revision.Revision {
+			revision.Id = stored.Id
+			revision.Created = stored.Created
+			revision.Data = copyData(stored.Data)
+
+			return revision.Id
+		}
+	}
+
+	return 0
+}
+
+func (repo *MemoryType) RollbackLevel(level *LevelType, revision int64, constraints config.ConstraintsType) (status error) {
+	var (
+		stored LevelType
+		ok     bool
+	)
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	stored, ok = repo.levels[level.Id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	// older data becomes new revision, so history is never lost
+	for _, older := range repo.revisions[level.Id] {
+		if older.Revision == revision {
+			level.GameId = stored.GameId
+			level.Profile = repo.games[stored.GameId].Profile
+			level.Level = stored.Level
+			level.Data = copyData(older.Data)
+
+			if repo.saveLevel(level, constraints) < 1 {
+				return ErrStorage
+			}
+
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (repo *MemoryType) GetLevels(game *GameType) (levels []LevelType, gameId int64) {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
+	game.CreatorId = repo.findCreator(game.Creator)
+	if game.CreatorId == 0 {
+		return nil, 0
+	}
+
+	game.Id = repo.findGame(game.CreatorId, game.Game)
+	if game.Id == 0 {
+		return nil, 0
+	}
+
+	levels = []LevelType{}
+	for _, stored := range repo.levels {
+		var (
+			level LevelType
+		)
+
+		if stored.GameId != game.Id {
+			continue
+		}
+
+		repo.fillLevel(&level, stored)
+		levels = append(levels, level)
+	}
+
+	sort.Slice(levels, func(i, j int) bool {
+		return levels[i].Level < levels[j].Level
+	})
+
+	return levels, game.Id
+}
+
+func (repo *MemoryType) DeleteGame(game *GameType) (status error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	game.CreatorId = repo.findCreator(game.Creator)
+	game.Id = repo.findGame(game.CreatorId, game.Game)
+	if (game.CreatorId == 0) || (game.Id == 0) {
+		return ErrNotFound
+	}
+
+	// cascade from game to levels and revisions
+	repo.deleteLevels(game.Id)
+	delete(repo.games, game.Id)
+
+	return nil
+}
+
+func (repo *MemoryType) UpdateGame(game *GameType, name string, profile *string) (status error) {
+	var (
+		stored GameType
+	)
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	game.CreatorId = repo.findCreator(game.Creator)
+	game.Id = repo.findGame(game.CreatorId, game.Game)
+	if (game.CreatorId == 0) || (game.Id == 0) {
+		return ErrNotFound
+	}
+
+	// game's name is unique for creator, the current name is not a conflict
+	if (name != "") && (name != game.Game) && (repo.findGame(game.CreatorId, name) != 0) {
+		return ErrConflict
+	}
+
+	stored = repo.games[game.Id]
+
+	if name != "" {
+		stored.Game = name
+	}
+
+	if profile != nil {
+		stored.Profile = *profile
+	}
+
+	repo.games[game.Id] = stored
+
+	game.Game = stored.Game
+	game.Profile = stored.Profile
+
+	return nil
+}
+
+func (repo *MemoryType) LoadGame(game *GameType) (status error) {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
+	game.CreatorId = repo.findCreator(game.Creator)
+	game.Id = repo.findGame(game.CreatorId, game.Game)
+	if (game.CreatorId == 0) || (game.Id == 0) {
+		return ErrNotFound
+	}
+
+	game.Profile = repo.games[game.Id].Profile
+
+	return nil
+}
+
+func (repo *MemoryType) ImportGame(archive *GameArchiveType, conflict string, constraints config.ConstraintsType) (result ImportResultType, status error) {
+	var (
+		creatorId, gameId int64
+		name              string
+		levels            []LevelType
+	)
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	result = ImportResultType{Creator: archive.Creator, Game: archive.Game, Status: ImportCreated, Levels: []int64{}}
+
+	// conflict is resolved and levels are prepared before any change, so failed import leaves repository as it was
+	creatorId = repo.findCreator(archive.Creator)
+	gameId = repo.findGame(creatorId, archive.Game)
+
+	if (creatorId != 0) && (gameId != 0) {
+		switch conflict {
+		case ConflictSkip:
+			result.Status = ImportSkipped
+			return result, nil
+
+		case ConflictOverwrite:
+			// levels are saved as usual uploads, so history of existing levels is kept in revisions
+			result.Status = ImportOverwritten
+
+		case ConflictRename:
+			result.Status = ImportRenamed
+
+			// the first free name among "game (2)", "game (3)" and so on
+			for number := 2; repo.findGame(creatorId, result.Game) != 0; number++ {
+				result.Game = fmt.Sprintf("%s (%d)", archive.Game, number)
+			}
+
+			gameId = 0
+
+		default:
+			return result, ErrConflict
+		}
+	}
+
+	name = result.Game
+
+	levels = make([]LevelType, len(archive.Levels))
+	for i, archived := range archive.Levels {
+		levels[i] = LevelType{Profile: archive.Profile, Creator: archive.Creator, Game: name,
+			Level: archived.Level, Data: archived.Data}
+
+		if !levels[i].prepareLevel(constraints) {
+			return result, ErrStorage
+		}
+	}
+
+	// nothing below fails, so repository gets either whole game or nothing
+	if creatorId == 0 {
+		repo.creatorSeq++
+		creatorId = repo.creatorSeq
+		repo.creators[creatorId] = archive.Creator
+	}
+
+	if gameId == 0 {
+		repo.gameSeq++
+		gameId = repo.gameSeq
+	}
+
+	repo.games[gameId] = GameType{Id: gameId, CreatorId: creatorId, Game: name, Profile: archive.Profile}
+
+	for i := range levels {
+		levels[i].CreatorId = creatorId
+		levels[i].GameId = gameId
+
+		result.Levels = append(result.Levels, repo.putLevel(&levels[i]))
+	}
+
+	return result, nil
+}
+
+func (repo *MemoryType) GetGames(creator *CreatorType) (games []GameType, creatorId int64) {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+
+	creatorId = repo.findCreator(creator.Creator)
+	if creatorId == 0 {
+		return nil, 0
+	}
+
+	games = []GameType{}
+	for _, game := range repo.games {
+		if game.CreatorId != creatorId {
+			continue
+		}
+
+		game.Creator = creator.Creator
+		games = append(games, game)
+	}
+
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].Game < games[j].Game
+	})
+
+	return games, creatorId
+}
+
+func (repo *MemoryType) DeleteCreator(creator *CreatorType) (status error) {
+	var (
+		creatorId int64
+	)
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	creatorId = repo.findCreator(creator.Creator)
+	if creatorId == 0 {
+		return ErrNotFound
+	}
+
+	// cascade from creator to games, levels and revisions
+	for gameId, game := range repo.games {
+		if game.CreatorId == creatorId {
+			repo.deleteLevels(gameId)
+			delete(repo.games, gameId)
+		}
+	}
+
+	delete(repo.creators, creatorId)
+
+	return nil
+}
+
+func (repo *MemoryType) RenameCreator(creator *CreatorType, name string) (status error) {
+	var (
+		creatorId int64
+	)
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	creatorId = repo.findCreator(creator.Creator)
+	if creatorId == 0 {
+		return ErrNotFound
+	}
+
+	// creator's name is unique, the current name is not a conflict
+	if name == creator.Creator {
+		return nil
+	}
+
+	if repo.findCreator(name) != 0 {
+		return ErrConflict
+	}
+
+	repo.creators[creatorId] = name
+	creator.Creator = name
+
+	return nil
+}
+
+// analyze level and store its copy with new revision. level's game must be set. caller must hold write lock.
+// return id for level
+func (repo *MemoryType) saveLevel(level *LevelType, constraints config.ConstraintsType) (levelId int64) {
+	if !level.prepareLevel(constraints) {
+		return -1
+	}
+
+	return repo.putLevel(level)
+}
+
+// store copy of prepared level and add its new revision, it can't fail. caller must hold write lock.
+// return id for level
+func (repo *MemoryType) putLevel(level *LevelType) (levelId int64) {
+	var (
+		stored LevelType
+	)
+
+	// level with the same game and number is updated
+	level.Id = repo.findLevel(level.GameId, level.Level)
+	if level.Id == 0 {
+		repo.levelSeq++
+		level.Id = repo.levelSeq
+	}
+
+	stored = LevelType{Id: level.Id, GameId: level.GameId, Level: level.Level, Data: copyData(level.Data), Analysis: level.Analysis}
+	stored.Analysis.Path = append([][2]int(nil), level.Analysis.Path...)
+	repo.levels[level.Id] = stored
+
+	// every upload is new revision of level
+	repo.revisionSeq++
+	repo.revisions[level.Id] = append(repo.revisions[level.Id], RevisionType{
+		Id:       repo.revisionSeq,
+		LevelId:  level.Id,
+		Revision: int64(len(repo.revisions[level.Id]) + 1),
+		Created:  time.Now(),
+		Data:     copyData(level.Data),
+	})
+
+	return level.Id
+}
+
+// copy stored level into level structure together with creator's and game's names
+func (repo *MemoryType) fillLevel(level *LevelType, stored LevelType) {
+	var (
+		game GameType
+	)
+
+	game = repo.games[stored.GameId]
+
+	level.Id = stored.Id
+	level.GameId = stored.GameId
+	level.CreatorId = game.CreatorId
+	level.Game = game.Game
+	level.Profile = game.Profile
+	level.Creator = repo.creators[game.CreatorId]
+	level.Level = stored.Level
+	level.Data = copyData(stored.Data)
+	level.Analysis = stored.Analysis
+	level.Analysis.Path = append([][2]int(nil), stored.Analysis.Path...)
+}
+
+// delete all levels of game with their revisions. caller must hold write lock.
+func (repo *MemoryType) deleteLevels(gameId int64) {
+	for levelId, level := range repo.levels {
+		if level.GameId == gameId {
+			delete(repo.revisions, levelId)
+			delete(repo.levels, levelId)
+		}
+	}
+}
+
+// return id for creator with specific name, 0 if creator doesn't exist
+func (repo *MemoryType) findCreator(name string) (id int64) {
+	for id, creator := range repo.creators {
+		if creator == name {
+			return id
+		}
+	}
+
+	return 0
+}
+
+// return id for creator's game with specific name, 0 if game doesn't exist
+func (repo *MemoryType) findGame(creatorId int64, name string) (id int64) {
+	for id, game := range repo.games {
+		if (game.CreatorId == creatorId) && (game.Game == name) {
+			return id
+		}
+	}
+
+	return 0
+}
+
+// return id for game's level with specific number, 0 if level doesn't exist
+func (repo *MemoryType) findLevel(gameId int64, number int64) (id int64) {
+	for id, level := range repo.levels {
+		if (level.GameId == gameId) && (level.Level == number) {
+			return id
+		}
+	}
+
+	return 0
+}
+
+// return deep copy of level data
+func copyData(data [][]int) (copied [][]int) {
+	if data == nil {
+		return nil
+	}
+
+	copied = make([][]int, len(data))
+	for y, line := range data {
+		copied[y] = append([]int(nil), line...)
+	}
+
+	return copied
+}
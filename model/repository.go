@@ -0,0 +1,121 @@
+package model
+
+import (
+	"database/sql"
+	"greenjade/config"
+)
+
+const (
+	StoragePostgres = config.DriverPostgres // storage driver - PostgreSQL db, default one
+	StorageSQLite   = config.DriverSQLite   // storage driver - embedded SQLite db file, for local level-design sessions
+	StorageMemory   = config.DriverMemory   // storage driver - process memory, data is lost on restart
+)
+
+// storage of creators, games, levels and their revisions. results follow conventions of model methods:
+// id is 0 if entity doesn't exist and -1 on error, status is nil, ErrNotFound, ErrConflict or ErrStorage.
+type RepositoryType interface {
+	StoreLevel(level *LevelType, constraints config.ConstraintsType) (levelId int64)
+	LoadLevelById(level *LevelType, id int64) (levelId int64)
+	LoadLevelByNumber(level *LevelType) (levelId int64)
+	DeleteLevel(level *LevelType) (status error)
+	RenumberLevel(level *LevelType, number int64) (status error)
+
+	GetRevisions(level *LevelType) (revisions []RevisionType, levelId int64)
+	LoadRevision(revision *RevisionType) (id int64)
+	RollbackLevel(level *LevelType, revision int64, constraints config.ConstraintsType) (status error)
+
+	GetLevels(game *GameType) (levels []LevelType, gameId int64)
+	DeleteGame(game *GameType) (status error)
+	UpdateGame(game *GameType, name string, profile *string) (status error)
+	LoadGame(game *GameType) (status error)
+	ImportGame(archive *GameArchiveType, conflict string, constraints config.ConstraintsType) (result ImportResultType, status error)
+
+	GetGames(creator *CreatorType) (games []GameType, creatorId int64)
+	DeleteCreator(creator *CreatorType) (status error)
+	RenameCreator(creator *CreatorType, name string) (status error)
+}
+
+// repository which keeps data in sql db, it passes db connection to model methods.
+// model's sql queries are the same for PostgreSQL and SQLite, only db connection differs.
+type SQLType struct {
+	DB *sql.DB
+}
+
+func (repo *SQLType) StoreLevel(level *LevelType, constraints config.ConstraintsType) (levelId int64) {
+	level.DB = repo.DB
+	return level.Store(constraints)
+}
+
+func (repo *SQLType) LoadLevelById(level *LevelType, id int64) (levelId int64) {
+	level.DB = repo.DB
+	return level.LoadById(id)
+}
+
+func (repo *SQLType) LoadLevelByNumber(level *LevelType) (levelId int64) {
+	level.DB = repo.DB
+	return level.LoadByNumber()
+}
+
+func (repo *SQLType) DeleteLevel(level *LevelType) (status error) {
+	level.DB = repo.DB
+	return level.Delete()
+}
+
+func (repo *SQLType) RenumberLevel(level *LevelType, number int64) (status error) {
+	level.DB = repo.DB
+	return level.Renumber(number)
+}
+
+func (repo *SQLType) GetRevisions(level *LevelType) (revisions []RevisionType, levelId int64) {
+	level.DB = repo.DB
+	return level.GetRevisions()
+}
+
+func (repo *SQLType) LoadRevision(revision *RevisionType) (id int64) {
+	revision.DB = repo.DB
+	return revision.Load()
+}
+
+func (repo *SQLType) RollbackLevel(level *LevelType, revision int64, constraints config.ConstraintsType) (status error) {
+	level.DB = repo.DB
+	return level.Rollback(revision, constraints)
+}
+
+func (repo *SQLType) GetLevels(game *GameType) (levels []LevelType, gameId int64) {
+	game.DB = repo.DB
+	return game.GetLevels()
+}
+
+func (repo *SQLType) DeleteGame(game *GameType) (status error) {
+	game.DB = repo.DB
+	return game.Delete()
+}
+
+func (repo *SQLType) UpdateGame(game *GameType, name string, profile *string) (status error) {
+	game.DB = repo.DB
+	return game.Update(name, profile)
+}
+
+func (repo *SQLType) LoadGame(game *GameType) (status error) {
+	game.DB = repo.DB
+	return game.Load()
+}
+
+func (repo *SQLType) ImportGame(archive *GameArchiveType, conflict string, constraints config.ConstraintsType) (result ImportResultType, status error) {
+	return archive.Import(repo.DB, conflict, constraints)
+}
+
+func (repo *SQLType) GetGames(creator *CreatorType) (games []GameType, creatorId int64) {
+	creator.DB = repo.DB
+	return creator.GetGames()
+}
+
+func (repo *SQLType) DeleteCreator(creator *CreatorType) (status error) {
+	creator.DB = repo.DB
+	return creator.Delete()
+}
+
+func (repo *SQLType) RenameCreator(creator *CreatorType, name string) (status error) {
+	creator.DB = repo.DB
+	return creator.Rename(name)
+}
@@ -0,0 +1,271 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"greenjade/config"
+	"time"
+)
+
+// structure describe single revision of level data
+type RevisionType struct {
+	DB       *sql.DB `json:"-"`
+	TX       *sql.Tx `json:"-"`
+	RawData  []byte  `json:"-"`
+	Id       int64
+	LevelId  int64
+	Revision int64
+	Created  time.Time
+	Data     [][]int `json:"Data,omitempty"`
+}
+
+// structure describe difference of single cell between two revisions, value is nil if cell doesn't exist
+type CellDiffType struct {
+	Point [2]int
+	From  *int
+	To    *int
+}
+
+// add new revision of level data with next revision number. prepare sql statement and execute it.
+// return id for new revision record
+func (obj *RevisionType) addRevision() (id int64) {
+	var (
+		err error
+
+		stmt *sql.Stmt
+	)
+
+	stmt, err = obj.TX.Prepare("INSERT INTO level_revisions (level_id, revision, data) " +
+		"SELECT $1, COALESCE(MAX(revision), 0) + 1, $2 FROM level_revisions WHERE (level_id = $1) RETURNING id, revision")
+	if err != nil {
+		fmt.Println("[error] add revision prepare:", err)
+		return -1
+	}
+
+	defer func() {
+		if err = stmt.Close(); err != nil {
+			fmt.Println("[error] add revision clear stmt memory:", err)
+		}
+	}()
+
+	err = stmt.QueryRow(obj.LevelId, obj.RawData).Scan(&obj.Id, &obj.Revision)
+	if err != nil {
+		fmt.Println("[error] add revision execute:", err)
+		return -1
+	}
+
+	return obj.Id
+}
+
+// load revision of level by level id and revision number which are set in revision structure.
+// prepare sql statement and execute it.
+// return id for revision record (0 if revision doesn't exist, -1 on error)
+func (obj *RevisionType) Load() (id int64) {
+	var (
+		err error
+
+		row *sql.Row
+	)
+
+	if obj.TX != nil {
+		row = obj.TX.QueryRow("SELECT id, created, data FROM level_revisions WHERE (level_id = $1) and (revision = $2)", obj.LevelId, obj.Revision)
+	} else {
+		row = obj.DB.QueryRow("SELECT id, created, data FROM level_revisions WHERE (level_id = $1) and (revision = $2)", obj.LevelId, obj.Revision)
+	}
+
+	err = row.Scan(&obj.Id, &obj.Created, &obj.RawData)
+	if err == sql.ErrNoRows {
+		return 0
+	}
+
+	if err != nil {
+		fmt.Println("[error] load revision scan row:", err)
+		return -1
+	}
+
+	obj.Data, err = decodeData(obj.RawData)
+	if err != nil {
+		fmt.Println("[error] load revision decode data:", err)
+		return -1
+	}
+
+	return obj.Id
+}
+
+// list all revisions of level which id is set in level structure, without level data.
+// prepare sql statement and execute it.
+// return revisions ordered by number and id for level (0 if level doesn't exist, -1 on error)
+func (obj *LevelType) GetRevisions() (revisions []RevisionType, levelId int64) {
+	var (
+		err error
+
+		stmt *sql.Stmt
+		row  *sql.Rows
+	)
+
+	levelId = obj.LoadById(obj.Id)
+	if levelId < 1 {
+		return nil, levelId
+	}
+
+	stmt, err = obj.DB.Prepare("SELECT id, revision, created FROM level_revisions WHERE (level_id = $1) ORDER BY revision")
+	if err != nil {
+		fmt.Println("[error] get revisions prepare:", err)
+		return nil, -1
+	}
+
+	defer func() {
+		if err = stmt.Close(); err != nil {
+			fmt.Println("[error] get revisions clear stmt memory:", err)
+		}
+	}()
+
+	row, err = stmt.Query(levelId)
+	if err != nil {
+		fmt.Println("[error] get revisions query statement:", err)
+		return nil, -1
+	}
+
+	defer func() {
+		if err = row.Close(); err != nil {
+			fmt.Println("[error] get revisions clear row memory:", err)
+		}
+	}()
+
+	revisions = []RevisionType{}
+
+	for row.Next() {
+		var (
+			revision RevisionType
+		)
+
+		err = row.Scan(&revision.Id, &revision.Revision, &revision.Created)
+		if err != nil {
+			fmt.Println("[error] get revisions scan row:", err)
+			return nil, -1
+		}
+
+		revision.LevelId = levelId
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, levelId
+}
+
+// roll back level which id is set in level structure to older revision in single transaction.
+// older data becomes new revision, so history is never lost.
+// return nil, ErrNotFound or ErrStorage
+func (obj *LevelType) Rollback(revisionNumber int64, constraints config.ConstraintsType) (status error) {
+	var (
+		err error
+
+		tx *sql.Tx
+
+		revision RevisionType
+	)
+
+	tx, err = obj.DB.Begin()
+	if err != nil {
+		fmt.Println("[error] rollback level begin transaction:", err)
+		return ErrStorage
+	}
+
+	defer rollback(tx, "rollback level")
+
+	obj.TX = tx
+
+	// level's game, its profile and number are required to save level
+	err = tx.QueryRow("SELECT l.game_id, g.profile, l.level FROM levels l JOIN games g ON (g.id = l.game_id) WHERE (l.id = $1)", obj.Id).Scan(&obj.GameId, &obj.Profile, &obj.Level)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+
+	if err != nil {
+		fmt.Println("[error] rollback level scan row:", err)
+		return ErrStorage
+	}
+
+	revision = RevisionType{TX: tx, LevelId: obj.Id, Revision: revisionNumber}
+	switch revision.Load() {
+	case -1:
+		return ErrStorage
+	case 0:
+		return ErrNotFound
+	}
+
+	obj.Data = revision.Data
+
+	if obj.saveLevel(constraints) < 1 {
+		return ErrStorage
+	}
+
+	return commit(tx, "rollback level")
+}
+
+// compare two level's data cell by cell, levels may have different size.
+// return list of changed cells ordered by rows and columns
+func DiffData(from, to [][]int) (cells []CellDiffType) {
+	var (
+		height int
+	)
+
+	cells = []CellDiffType{}
+
+	height = len(from)
+	if len(to) > height {
+		height = len(to)
+	}
+
+	for y := 0; y < height; y++ {
+		var (
+			width int
+		)
+
+		width = lineLen(from, y)
+		if lineLen(to, y) > width {
+			width = lineLen(to, y)
+		}
+
+		for x := 0; x < width; x++ {
+			var (
+				fromValue, toValue *int
+			)
+
+			fromValue = cellValue(from, y, x)
+			toValue = cellValue(to, y, x)
+
+			// cell is not changed if it exists in both levels with the same value
+			if (fromValue != nil) && (toValue != nil) && (*fromValue == *toValue) {
+				continue
+			}
+
+			cells = append(cells, CellDiffType{Point: [2]int{y, x}, From: fromValue, To: toValue})
+		}
+	}
+
+	return cells
+}
+
+// return length of level's line, 0 if line doesn't exist
+func lineLen(data [][]int, y int) int {
+	if y >= len(data) {
+		return 0
+	}
+
+	return len(data[y])
+}
+
+// return pointer to copy of cell value, nil if cell doesn't exist
+func cellValue(data [][]int, y, x int) *int {
+	var (
+		value int
+	)
+
+	if x >= lineLen(data, y) {
+		return nil
+	}
+
+	value = data[y][x]
+
+	return &value
+}
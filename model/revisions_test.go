@@ -0,0 +1,43 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestDiffData(t *testing.T) {
+	var (
+		from, to [][]int
+		cells    []CellDiffType
+	)
+
+	from = [][]int{
+		{1, 0, 1},
+		{1, 4, 1},
+	}
+
+	to = [][]int{
+		{1, 0, 1},
+		{1, 4, 0, 1},
+		{1, 1},
+	}
+
+	cells = DiffData(from, to)
+	if len(cells) != 4 {
+		t.Errorf("expected 4 changed cells, got %d", len(cells))
+		t.FailNow()
+	}
+
+	// changed value
+	if (cells[0].Point != [2]int{1, 2}) || (*cells[0].From != 1) || (*cells[0].To != 0) {
+		t.Errorf("unexpected diff for changed cell: %v", cells[0])
+	}
+
+	// added cells have no previous value
+	if (cells[1].Point != [2]int{1, 3}) || (cells[1].From != nil) || (*cells[1].To != 1) {
+		t.Errorf("unexpected diff for added cell: %v", cells[1])
+	}
+
+	if len(DiffData(from, from)) != 0 {
+		t.Error("expected no difference for the same data")
+	}
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"fmt"
+	"greenjade/analyze"
+	"greenjade/config"
+)
+
+// apply structural rules to level data, rules specify in config file section constraints.rules.
+// every broken rule is added to validation as separate violation with point (if it has one).
+func (obj *LevelType) validateRules(constraints config.ConstraintsType, validation *ValidationErrorType) {
+	var (
+		heroes [][2]int
+	)
+
+	heroes = analyze.FindPoints(obj.Data, analyze.HeroPoint)
+
+	// level must have exactly one hero
+	if constraints.Rules.SingleHero {
+		if len(heroes) == 0 {
+			validation.add(ViolationNoHero, -1, -1, "level must have hero")
+		}
+
+		if len(heroes) > 1 {
+			for _, hero := range heroes {
+				validation.add(ViolationMultipleHeroes, hero[0], hero[1], fmt.Sprintf("level must have single hero, extra hero in point [%d,%d]", hero[0]+1, hero[1]+1))
+			}
+		}
+	}
+
+	// level must have at least one exit
+	if constraints.Rules.RequireExit && (len(analyze.FindExits(obj.Data)) == 0) {
+		validation.add(ViolationNoExit, -1, -1, "level must have at least one exit")
+	}
+
+	if constraints.Rules.WallBorder {
+		obj.validateBorder(validation)
+	}
+
+	if constraints.Rules.TrapDensity.Check {
+		obj.validateTrapDensity(constraints.Rules.TrapDensity.Max, validation)
+	}
+
+	// areas are isolated from hero, so there is no sense to check them without single hero
+	if constraints.Rules.IsolatedAreas.Check && (len(heroes) == 1) {
+		obj.validateIsolatedAreas(heroes[0], constraints.Rules.IsolatedAreas.Max, validation)
+	}
+}
+
+// level must be enclosed by walls, only exits are allowed in border
+func (obj *LevelType) validateBorder(validation *ValidationErrorType) {
+	var (
+		exits map[[2]int]bool
+	)
+
+	exits = make(map[[2]int]bool)
+	for _, exit := range analyze.FindExits(obj.Data) {
+		exits[exit] = true
+	}
+
+	for row, line := range obj.Data {
+		for column, value := range line {
+			if (value == analyze.WallPoint) || exits[[2]int{row, column}] || !analyze.IsBorder(obj.Data, row, column) {
+				continue
+			}
+
+			validation.add(ViolationOpenBorder, row, column, fmt.Sprintf("level must be enclosed by walls except exits, broken value %d in point [%d,%d]", value, row+1, column+1))
+		}
+	}
+}
+
+// traps must take not more than max percent of points which are not walls
+func (obj *LevelType) validateTrapDensity(max int, validation *ValidationErrorType) {
+	var (
+		traps, passable int
+	)
+
+	for _, line := range obj.Data {
+		for _, value := range line {
+			switch value {
+			case analyze.WallPoint:
+				continue
+			case analyze.PitTrapPoint, analyze.ArrowTrapPoint:
+				traps++
+			}
+
+			passable++
+		}
+	}
+
+	if traps*100 > max*passable {
+		validation.add(ViolationTrapDensity, -1, -1, fmt.Sprintf("traps cannot take more than %d%% of level, there are %d traps among %d points", max, traps, passable))
+	}
+}
+
+// count areas which hero can't reach, whatever traps are on the way. if there are more than max such areas,
+// every one is reported by its first point
+func (obj *LevelType) validateIsolatedAreas(hero [2]int, max int, validation *ValidationErrorType) {
+	var (
+		isolated [][][2]int
+	)
+
+	for _, area := range analyze.FindAreas(obj.Data) {
+		var (
+			reachable bool
+		)
+
+		for _, cell := range area {
+			if cell == hero {
+				reachable = true
+				break
+			}
+		}
+
+		if !reachable {
+			isolated = append(isolated, area)
+		}
+	}
+
+	if len(isolated) <= max {
+		return
+	}
+
+	for _, area := range isolated {
+		validation.add(ViolationIsolatedArea, area[0][0], area[0][1], fmt.Sprintf("level cannot have more than %d areas isolated from hero, area of %d points starts in point [%d,%d]", max, len(area), area[0][0]+1, area[0][1]+1))
+	}
+}
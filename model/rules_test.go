@@ -0,0 +1,142 @@
+package model
+
+import (
+	"greenjade/config"
+	"testing"
+)
+
+// enable every structural rule with specific caps
+func buildRules(trapDensity, isolatedAreas int) (constraints config.ConstraintsType) {
+	var (
+		cfg *config.ConfType
+	)
+
+	// constraints are built in code, so test doesn't depend on config file of environment
+	cfg = &config.ConfType{}
+	cfg.SetDefaults()
+
+	constraints = cfg.Constraints
+	constraints.RequireSolvable = false
+	constraints.Rules.SingleHero = true
+	constraints.Rules.RequireExit = true
+	constraints.Rules.WallBorder = true
+	constraints.Rules.TrapDensity.Check = true
+	constraints.Rules.TrapDensity.Max = trapDensity
+	constraints.Rules.IsolatedAreas.Check = true
+	constraints.Rules.IsolatedAreas.Max = isolatedAreas
+
+	return constraints
+}
+
+func TestValidateRulesOk(t *testing.T) {
+	var (
+		status error
+	)
+
+	level := LevelType{Data: [][]int{
+		{1, 1, 5, 1},
+		{1, 0, 2, 1},
+		{1, 4, 0, 1},
+		{1, 1, 1, 1},
+	}}
+
+	status = level.Validate(buildRules(25, 0))
+	if status != nil {
+		t.Errorf("unexpected violations: %s", status.Error())
+	}
+}
+
+func TestValidateRulesViolations(t *testing.T) {
+	var (
+		status error
+
+		validation *ValidationErrorType
+		ok         bool
+	)
+
+	level := LevelType{Data: [][]int{
+		{1, 1, 1, 1, 1},
+		{2, 4, 1, 0, 1},
+		{1, 4, 1, 1, 1},
+		{1, 3, 1, 0, 1},
+		{1, 1, 1, 1, 1},
+	}}
+
+	status = level.Validate(buildRules(10, 1))
+
+	validation, ok = status.(*ValidationErrorType)
+	if !ok {
+		t.Errorf("unexpected result %v", status)
+		t.FailNow()
+	}
+
+	expected := []struct {
+		code        string
+		row, column int
+	}{
+		{ViolationMultipleHeroes, 1, 1},
+		{ViolationMultipleHeroes, 2, 1},
+		{ViolationNoExit, -1, -1},
+		{ViolationOpenBorder, 1, 0},
+		{ViolationTrapDensity, -1, -1},
+	}
+
+	if len(validation.Violations) != len(expected) {
+		t.Errorf("expected %d violations, got %s", len(expected), validation.Error())
+		t.FailNow()
+	}
+
+	for i, violation := range validation.Violations {
+		var (
+			row, column int
+		)
+
+		row, column = -1, -1
+		if violation.Row != nil {
+			row = *violation.Row
+		}
+
+		if violation.Column != nil {
+			column = *violation.Column
+		}
+
+		if (violation.Code != expected[i].code) || (row != expected[i].row) || (column != expected[i].column) {
+			t.Errorf("violation %d: expected %s in [%d,%d], got %s in [%d,%d]", i, expected[i].code, expected[i].row, expected[i].column, violation.Code, row, column)
+		}
+	}
+}
+
+func TestValidateRulesIsolatedAreas(t *testing.T) {
+	var (
+		status error
+
+		validation *ValidationErrorType
+		ok         bool
+	)
+
+	level := LevelType{Data: [][]int{
+		{1, 5, 1, 1, 1},
+		{1, 4, 1, 0, 1},
+		{1, 1, 1, 1, 1},
+		{1, 0, 1, 0, 1},
+		{1, 1, 1, 1, 1},
+	}}
+
+	// three isolated areas are allowed
+	status = level.Validate(buildRules(100, 3))
+	if status != nil {
+		t.Errorf("unexpected violations: %s", status.Error())
+	}
+
+	status = level.Validate(buildRules(100, 2))
+
+	validation, ok = status.(*ValidationErrorType)
+	if !ok || (len(validation.Violations) != 3) {
+		t.Errorf("expected 3 isolated areas, got %v", status)
+		t.FailNow()
+	}
+
+	if (validation.Violations[2].Code != ViolationIsolatedArea) || (*validation.Violations[2].Row != 3) || (*validation.Violations[2].Column != 3) {
+		t.Errorf("unexpected last violation: %v", validation.Violations[2])
+	}
+}
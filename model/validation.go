@@ -0,0 +1,79 @@
+package model
+
+import (
+	"strings"
+)
+
+const (
+	ViolationEmptyLevel     = "empty_level"     // level has no lines at all
+	ViolationTooFewLines    = "too_few_lines"   // level has less lines than required
+	ViolationTooManyLines   = "too_many_lines"  // level has more lines than allowed
+	ViolationLineTooShort   = "line_too_short"  // line is shorter than required
+	ViolationLineTooLong    = "line_too_long"   // line is longer than allowed
+	ViolationNotRectangular = "not_rectangular" // line length differs from the first line length
+	ViolationInvalidPoint   = "invalid_point"   // point value is out of allowed range
+
+	ViolationNoHero         = "no_hero"         // level has no hero marker
+	ViolationMultipleHeroes = "multiple_heroes" // level has more than one hero marker, each one is reported
+	ViolationNoExit         = "no_exit"         // level has no exit
+	ViolationOpenBorder     = "open_border"     // border point is neither wall nor exit
+	ViolationTrapDensity    = "trap_density"    // too many traps among points which are not walls
+	ViolationIsolatedArea   = "isolated_area"   // area which hero can't reach, each one is reported by its first point
+)
+
+// structure describe single violation of level constraints. row and column are numbered from 0,
+// they are omitted if violation concerns whole level (or whole line for column).
+// level is number of level, it's set only for violations of several levels, e.g. of game archive.
+type ViolationType struct {
+	Code    string `json:"code"`
+	Level   *int64 `json:"level,omitempty"`
+	Row     *int   `json:"row,omitempty"`
+	Column  *int   `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// error which collects all violations of level constraints
+type ValidationErrorType struct {
+	Violations []ViolationType `json:"violations"`
+}
+
+// join messages of all violations
+func (obj *ValidationErrorType) Error() string {
+	var (
+		messages []string
+	)
+
+	for _, violation := range obj.Violations {
+		messages = append(messages, violation.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// add violation with code and message. negative row or column means that violation is not bound to it.
+func (obj *ValidationErrorType) add(code string, row, column int, message string) {
+	var (
+		violation ViolationType
+	)
+
+	violation = ViolationType{Code: code, Message: message}
+
+	if row > -1 {
+		violation.Row = &row
+	}
+
+	if column > -1 {
+		violation.Column = &column
+	}
+
+	obj.Violations = append(obj.Violations, violation)
+}
+
+// return collected violations as error, nil if there is no violation
+func (obj *ValidationErrorType) status() error {
+	if len(obj.Violations) == 0 {
+		return nil
+	}
+
+	return obj
+}
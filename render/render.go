@@ -0,0 +1,287 @@
+// package render draw labyrinth level and its minimal survivable path as image
+package render
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"greenjade/analyze"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+)
+
+const (
+	FormatPNG = "png" // raster image
+	FormatSVG = "svg" // vector image
+
+	DefaultCellSize = 16 // size of single point in pixels
+	MaxCellSize     = 64 // bigger points make too large images
+)
+
+// error of unknown image format
+var ErrUnknownFormat = errors.New("unknown image format, expected png or svg")
+
+// colors of labyrinth level essences, points with unknown value are drawn as open tiles
+var (
+	colorOpen  = color.RGBA{R: 0xf5, G: 0xf5, B: 0xf0, A: 0xff}
+	colorWall  = color.RGBA{R: 0x3c, G: 0x3c, B: 0x46, A: 0xff}
+	colorPit   = color.RGBA{R: 0x1e, G: 0x14, B: 0x0a, A: 0xff}
+	colorArrow = color.RGBA{R: 0xe6, G: 0x8c, B: 0x14, A: 0xff}
+	colorHero  = color.RGBA{R: 0x1e, G: 0x64, B: 0xdc, A: 0xff}
+	colorExit  = color.RGBA{R: 0x28, G: 0xb4, B: 0x50, A: 0xff}
+	colorPath  = color.RGBA{R: 0xdc, G: 0x28, B: 0x28, A: 0xff}
+)
+
+// options of rendering: size of point and optional path which is drawn over level
+type OptionsType struct {
+	CellSize int
+	Path     [][2]int
+}
+
+// draw level data in specific format.
+// return encoded image or error if format is unknown
+func Render(format string, labyrinthData [][]int, options OptionsType) (output []byte, mediaType string, err error) {
+	if (options.CellSize < 1) || (options.CellSize > MaxCellSize) {
+		options.CellSize = DefaultCellSize
+	}
+
+	switch strings.ToLower(format) {
+	case FormatPNG:
+		output, err = PNG(labyrinthData, options)
+		return output, "image/png", err
+	case FormatSVG:
+		return SVG(labyrinthData, options), "image/svg+xml", nil
+	}
+
+	return nil, "", ErrUnknownFormat
+}
+
+// draw level as png image: each point is square of essence's color, traps, hero and exits have marker inside.
+// exits are the same as analysis finds: explicit markers, or gaps in border if level has no marker.
+// return encoded png image
+func PNG(labyrinthData [][]int, options OptionsType) (output []byte, err error) {
+	var (
+		canvas *image.RGBA
+		buffer bytes.Buffer
+		exits  map[[2]int]bool
+
+		width, height, size int
+	)
+
+	size = options.CellSize
+	width, height = measure(labyrinthData)
+	exits = findExits(labyrinthData)
+
+	// level without points is drawn as single empty point
+	canvas = image.NewRGBA(image.Rect(0, 0, maxInt(width, 1)*size, maxInt(height, 1)*size))
+	fillRect(canvas, canvas.Bounds(), colorOpen)
+
+	for y, line := range labyrinthData {
+		for x, value := range line {
+			var (
+				cell image.Rectangle
+			)
+
+			cell = image.Rect(x*size, y*size, (x+1)*size, (y+1)*size)
+
+			if exits[[2]int{y, x}] {
+				fillRect(canvas, cell.Inset(size/8), colorExit)
+				continue
+			}
+
+			switch value {
+			case analyze.WallPoint:
+				fillRect(canvas, cell, colorWall)
+			case analyze.PitTrapPoint:
+				fillCircle(canvas, cell, 0.4, colorPit)
+			case analyze.ArrowTrapPoint:
+				fillTriangle(canvas, cell, colorArrow)
+			case analyze.HeroPoint:
+				fillCircle(canvas, cell, 0.35, colorHero)
+			}
+		}
+	}
+
+	// path is polyline through centers of points
+	for i := 1; i < len(options.Path); i++ {
+		drawLine(canvas, center(options.Path[i-1], size), center(options.Path[i], size), maxInt(size/6, 1), colorPath)
+	}
+
+	err = png.Encode(&buffer, canvas)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// draw level as svg image with the same visuals as png one.
+// return svg document
+func SVG(labyrinthData [][]int, options OptionsType) (output []byte) {
+	var (
+		builder strings.Builder
+		exits   map[[2]int]bool
+
+		width, height, size int
+	)
+
+	size = options.CellSize
+	width, height = measure(labyrinthData)
+	exits = findExits(labyrinthData)
+
+	fmt.Fprintf(&builder, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		maxInt(width, 1)*size, maxInt(height, 1)*size, maxInt(width, 1)*size, maxInt(height, 1)*size)
+	fmt.Fprintf(&builder, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hex(colorOpen))
+
+	for y, line := range labyrinthData {
+		for x, value := range line {
+			var (
+				left, top, half float64
+			)
+
+			left, top, half = float64(x*size), float64(y*size), float64(size)/2
+
+			if exits[[2]int{y, x}] {
+				fmt.Fprintf(&builder, `<rect class="exit" x="%g" y="%g" width="%g" height="%g" fill="%s"/>`+"\n", left+half/4, top+half/4, half*1.5, half*1.5, hex(colorExit))
+				continue
+			}
+
+			switch value {
+			case analyze.WallPoint:
+				fmt.Fprintf(&builder, `<rect class="wall" x="%g" y="%g" width="%d" height="%d" fill="%s"/>`+"\n", left, top, size, size, hex(colorWall))
+			case analyze.PitTrapPoint:
+				fmt.Fprintf(&builder, `<circle class="pit" cx="%g" cy="%g" r="%g" fill="%s"/>`+"\n", left+half, top+half, half*0.8, hex(colorPit))
+			case analyze.ArrowTrapPoint:
+				fmt.Fprintf(&builder, `<polygon class="arrow" points="%g,%g %g,%g %g,%g" fill="%s"/>`+"\n",
+					left+half*0.2, top+half*0.2, left+half*1.8, top+half, left+half*0.2, top+half*1.8, hex(colorArrow))
+			case analyze.HeroPoint:
+				fmt.Fprintf(&builder, `<circle class="hero" cx="%g" cy="%g" r="%g" fill="%s"/>`+"\n", left+half, top+half, half*0.7, hex(colorHero))
+			}
+		}
+	}
+
+	if len(options.Path) > 1 {
+		var (
+			points []string
+		)
+
+		for _, point := range options.Path {
+			points = append(points, fmt.Sprintf("%g,%g", float64(point[1]*size)+float64(size)/2, float64(point[0]*size)+float64(size)/2))
+		}
+
+		fmt.Fprintf(&builder, `<polyline class="path" points="%s" fill="none" stroke="%s" stroke-width="%d" stroke-linecap="round" stroke-linejoin="round"/>`+"\n",
+			strings.Join(points, " "), hex(colorPath), maxInt(size/6, 1))
+	}
+
+	builder.WriteString("</svg>\n")
+
+	return []byte(builder.String())
+}
+
+// find exits of level once for whole image.
+// return set of [row, column] points of exits
+func findExits(labyrinthData [][]int) (exits map[[2]int]bool) {
+	exits = make(map[[2]int]bool)
+
+	for _, exit := range analyze.FindExits(labyrinthData) {
+		exits[exit] = true
+	}
+
+	return exits
+}
+
+// return width (the longest line) and height of level
+func measure(labyrinthData [][]int) (width, height int) {
+	for _, line := range labyrinthData {
+		width = maxInt(width, len(line))
+	}
+
+	return width, len(labyrinthData)
+}
+
+// return center of point in pixels
+func center(point [2]int, size int) image.Point {
+	return image.Pt(point[1]*size+size/2, point[0]*size+size/2)
+}
+
+// return color as #rrggbb
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+func fillRect(canvas *image.RGBA, rect image.Rectangle, c color.RGBA) {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			canvas.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// fill circle in the middle of cell, radius is part of cell size
+func fillCircle(canvas *image.RGBA, cell image.Rectangle, radius float64, c color.RGBA) {
+	var (
+		cx, cy, r float64
+	)
+
+	cx = float64(cell.Min.X+cell.Max.X) / 2
+	cy = float64(cell.Min.Y+cell.Max.Y) / 2
+	r = float64(cell.Dx()) * radius
+
+	for y := cell.Min.Y; y < cell.Max.Y; y++ {
+		for x := cell.Min.X; x < cell.Max.X; x++ {
+			if math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy) <= r {
+				canvas.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// fill triangle which points to the right inside of cell
+func fillTriangle(canvas *image.RGBA, cell image.Rectangle, c color.RGBA) {
+	var (
+		size, padding float64
+	)
+
+	size = float64(cell.Dx())
+	padding = size / 10
+
+	for y := cell.Min.Y; y < cell.Max.Y; y++ {
+		for x := cell.Min.X; x < cell.Max.X; x++ {
+			var (
+				dx, dy float64
+			)
+
+			dx = float64(x-cell.Min.X) + 0.5 - padding
+			dy = math.Abs(float64(y-cell.Min.Y) + 0.5 - size/2)
+
+			// half height of triangle decreases from left side to the tip
+			if (dx >= 0) && (dx <= size-2*padding) && (dy <= (size/2-padding)*(1-dx/(size-2*padding))) {
+				canvas.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// draw thick line between two points, path goes by sides of points, so lines are horizontal or vertical
+func drawLine(canvas *image.RGBA, from, to image.Point, width int, c color.RGBA) {
+	var (
+		rect image.Rectangle
+	)
+
+	rect = image.Rectangle{Min: from, Max: to}.Canon()
+	rect.Min = rect.Min.Sub(image.Pt(width/2, width/2))
+	rect.Max = rect.Max.Add(image.Pt(width-width/2, width-width/2))
+
+	fillRect(canvas, rect.Intersect(canvas.Bounds()), c)
+}
@@ -0,0 +1,110 @@
+package render
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+var level = [][]int{
+	{1, 1, 5, 1},
+	{1, 0, 2, 1},
+	{1, 4, 3, 1},
+	{1, 1, 1, 1},
+}
+
+func TestRenderPNG(t *testing.T) {
+	var (
+		err error
+
+		output    []byte
+		mediaType string
+	)
+
+	output, mediaType, err = Render(FormatPNG, level, OptionsType{CellSize: 10, Path: [][2]int{{2, 1}, {1, 1}, {1, 2}, {0, 2}}})
+	if (err != nil) || (mediaType != "image/png") {
+		t.Fatalf("unexpected result %q: %v", mediaType, err)
+	}
+
+	picture, err := png.Decode(bytes.NewReader(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (picture.Bounds().Dx() != 40) || (picture.Bounds().Dy() != 40) {
+		t.Errorf("expected 40x40 image, got %v", picture.Bounds())
+	}
+
+	// wall corner, and path which goes through the center of open tile
+	if r, g, b, _ := picture.At(0, 0).RGBA(); (r>>8 != uint32(colorWall.R)) || (g>>8 != uint32(colorWall.G)) || (b>>8 != uint32(colorWall.B)) {
+		t.Errorf("expected wall color in corner, got %v", picture.At(0, 0))
+	}
+
+	if r, _, _, _ := picture.At(15, 15).RGBA(); r>>8 != uint32(colorPath.R) {
+		t.Errorf("expected path color in center of open tile, got %v", picture.At(15, 15))
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	var (
+		err error
+
+		output []byte
+	)
+
+	output, _, err = Render("SVG", level, OptionsType{Path: [][2]int{{2, 1}, {1, 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{`width="64"`, `class="wall"`, `class="pit"`, `class="arrow"`, `class="hero"`, `class="exit"`, `points="24,40 24,24"`} {
+		if !strings.Contains(string(output), expected) {
+			t.Errorf("expected %s in svg:\n%s", expected, output)
+		}
+	}
+
+	_, _, err = Render("gif", level, OptionsType{})
+	if err != ErrUnknownFormat {
+		t.Errorf("expected unknown format, got %v", err)
+	}
+}
+
+func TestRenderBorderExits(t *testing.T) {
+	var (
+		err error
+
+		output []byte
+	)
+
+	// level without exit marker is left through gaps in border, they are drawn as exits
+	gaps := [][]int{
+		{1, 0, 1, 1},
+		{1, 4, 0, 0},
+		{1, 1, 1, 1},
+	}
+
+	output, err = PNG(gaps, OptionsType{CellSize: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	picture, err := png.Decode(bytes.NewReader(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// center of gap in top line and of gap in right column, open tile inside level stays open
+	for _, point := range [][3]int{{12, 4, 1}, {28, 12, 1}, {20, 12, 0}} {
+		r, g, b, _ := picture.At(point[0], point[1]).RGBA()
+		exit := (r>>8 == uint32(colorExit.R)) && (g>>8 == uint32(colorExit.G)) && (b>>8 == uint32(colorExit.B))
+		if exit != (point[2] == 1) {
+			t.Errorf("point %d,%d: expected exit %v, got %v", point[0], point[1], point[2] == 1, picture.At(point[0], point[1]))
+		}
+	}
+
+	output = SVG(gaps, OptionsType{CellSize: 8})
+	if strings.Count(string(output), `class="exit"`) != 2 {
+		t.Errorf("expected 2 exits in svg:\n%s", output)
+	}
+}
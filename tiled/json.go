@@ -0,0 +1,205 @@
+package tiled
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"greenjade/codec"
+)
+
+const (
+	MediaTypeJSON = "application/x-tiled+json" // media type of Tiled map in json format
+)
+
+// Tiled map in json format, only fields which are needed for level
+type jsonMapType struct {
+	Type         string             `json:"type"`
+	Version      string             `json:"version"`
+	Orientation  string             `json:"orientation"`
+	RenderOrder  string             `json:"renderorder"`
+	Width        int                `json:"width"`
+	Height       int                `json:"height"`
+	TileWidth    int                `json:"tilewidth"`
+	TileHeight   int                `json:"tileheight"`
+	Infinite     bool               `json:"infinite"`
+	NextLayerId  int                `json:"nextlayerid"`
+	NextObjectId int                `json:"nextobjectid"`
+	Properties   []jsonPropertyType `json:"properties,omitempty"`
+	Layers       []jsonLayerType    `json:"layers"`
+	Tilesets     []jsonTilesetType  `json:"tilesets"`
+}
+
+type jsonPropertyType struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type jsonTilesetType struct {
+	FirstGid int    `json:"firstgid"`
+	Source   string `json:"source"`
+}
+
+type jsonLayerType struct {
+	Id          int             `json:"id"`
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	Width       int             `json:"width"`
+	Height      int             `json:"height"`
+	X           int             `json:"x"`
+	Y           int             `json:"y"`
+	Opacity     float64         `json:"opacity"`
+	Visible     bool            `json:"visible"`
+	Encoding    string          `json:"encoding,omitempty"`
+	Compression string          `json:"compression,omitempty"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// codec of Tiled map in json format (.tmj), level is the first tile layer of map.
+// exported map refers to external tileset file, tile ids of mapping must be ids of this tileset
+type JSONType struct {
+	Mapping MappingType
+	Tileset string
+	MaxSide int // max width and height of map, zero means default max dimension
+}
+
+func (JSONType) Name() string {
+	return "tmj"
+}
+
+func (JSONType) MediaType() string {
+	return MediaTypeJSON
+}
+
+// return copy of codec with another max side of map
+func (obj JSONType) WithMaxSide(maxSide int) codec.CodecType {
+	obj.MaxSide = maxSide
+	return obj
+}
+
+func (obj JSONType) Decode(input []byte) (data [][]int, err error) {
+	_, data, err = obj.DecodeLevel(input)
+	return data, err
+}
+
+func (obj JSONType) Encode(data [][]int) (output []byte, err error) {
+	return obj.EncodeLevel(codec.MetaType{}, data)
+}
+
+// read level data from the first tile layer and level's creator, game and number from map properties.
+// return level meta and data
+func (obj JSONType) DecodeLevel(input []byte) (meta codec.MetaType, data [][]int, err error) {
+	var (
+		tmj        jsonMapType
+		gids       []uint32
+		text       string
+		properties map[string]string
+	)
+
+	err = json.Unmarshal(input, &tmj)
+	if err != nil {
+		return meta, nil, fmt.Errorf("invalid tiled json map: %w", err)
+	}
+
+	if tmj.Infinite {
+		return meta, nil, errors.New("infinite maps are not supported")
+	}
+
+	for _, layer := range tmj.Layers {
+		if layer.Type != "tilelayer" {
+			continue
+		}
+
+		err = checkSize(layer.Width, layer.Height, obj.MaxSide)
+		if err != nil {
+			return meta, nil, err
+		}
+
+		// data is array of tile ids, or base64 string
+		if (layer.Encoding == "") || (layer.Encoding == "csv") {
+			err = json.Unmarshal(layer.Data, &gids)
+		} else {
+			err = json.Unmarshal(layer.Data, &text)
+			if err == nil {
+				gids, err = decodeGids(layer.Encoding, layer.Compression, text, layer.Width*layer.Height)
+			}
+		}
+
+		if err != nil {
+			return meta, nil, fmt.Errorf("invalid layer data: %w", err)
+		}
+
+		data, err = obj.Mapping.toData(gids, layer.Width, layer.Height, obj.MaxSide)
+		if err != nil {
+			return meta, nil, err
+		}
+
+		properties = make(map[string]string)
+		for _, property := range tmj.Properties {
+			properties[property.Name] = fmt.Sprint(property.Value)
+		}
+
+		meta, err = metaFromProperties(properties)
+
+		return meta, data, err
+	}
+
+	return meta, nil, errors.New("map has no tile layer")
+}
+
+// write level data as single tile layer, level's creator, game and number are map properties.
+// return json document
+func (obj JSONType) EncodeLevel(meta codec.MetaType, data [][]int) (output []byte, err error) {
+	var (
+		tmj           jsonMapType
+		gids          []uint32
+		layerData     []byte
+		width, height int
+	)
+
+	gids, width, height, err = obj.Mapping.toGids(data)
+	if err != nil {
+		return nil, err
+	}
+
+	layerData, err = json.Marshal(gids)
+	if err != nil {
+		return nil, err
+	}
+
+	tmj = jsonMapType{
+		Type:         "map",
+		Version:      "1.10",
+		Orientation:  "orthogonal",
+		RenderOrder:  "right-down",
+		Width:        width,
+		Height:       height,
+		TileWidth:    DefaultTileSize,
+		TileHeight:   DefaultTileSize,
+		NextLayerId:  2,
+		NextObjectId: 1,
+		Properties: []jsonPropertyType{
+			{Name: propertyCreator, Type: "string", Value: meta.Creator},
+			{Name: propertyGame, Type: "string", Value: meta.Game},
+			{Name: propertyLevel, Type: "int", Value: meta.Level},
+		},
+		Layers: []jsonLayerType{{
+			Id:      1,
+			Name:    "level",
+			Type:    "tilelayer",
+			Width:   width,
+			Height:  height,
+			Opacity: 1,
+			Visible: true,
+			Data:    layerData,
+		}},
+		Tilesets: []jsonTilesetType{{FirstGid: 1, Source: obj.Tileset}},
+	}
+
+	output, err = json.MarshalIndent(tmj, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(output, '\n'), nil
+}
@@ -0,0 +1,308 @@
+// package tiled import and export levels as maps of Tiled editor (https://www.mapeditor.org), in TMX (xml) and
+// JSON formats. tiles of map are converted into level points by mapping of global tile ids.
+package tiled
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"greenjade/analyze"
+	"greenjade/codec"
+	"greenjade/config"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	flipFlags = 0xF0000000 // high bits of global tile id keep flipping and rotation of tile
+
+	propertyCreator = "creator" // map property with level's creator
+	propertyGame    = "game"    // map property with level's game
+	propertyLevel   = "level"   // map property with level's number
+
+	DefaultTileSize = 32              // size of tile in pixels for exported maps
+	DefaultTileset  = "labyrinth.tsx" // external tileset which exported maps refer to
+)
+
+// mapping of global tile id (as it's written in layer data, without flip flags) into level point
+type MappingType map[int]int
+
+// default mapping for tileset where tiles follow points order: 1 open, 2 wall, 3 pit, 4 arrow, 5 hero, 6 exit.
+// empty tile (0) is open tile.
+// return mapping
+func DefaultMapping() (mapping MappingType) {
+	mapping = MappingType{0: analyze.OpenTilePoint}
+
+	for point := analyze.OpenTilePoint; point <= analyze.ExitPoint; point++ {
+		mapping[point+1] = point
+	}
+
+	return mapping
+}
+
+// register tmx and tmj codecs with specific mapping, tileset and max side of map, empty values mean default ones
+func Register(mapping MappingType, tileset string, maxSide int) {
+	codec.Register(TMXType{Mapping: defaultMapping(mapping), Tileset: defaultTileset(tileset), MaxSide: maxSide})
+	codec.Register(JSONType{Mapping: defaultMapping(mapping), Tileset: defaultTileset(tileset), MaxSide: maxSide})
+}
+
+// return mapping or default one if it's empty
+func defaultMapping(mapping MappingType) MappingType {
+	if len(mapping) == 0 {
+		return DefaultMapping()
+	}
+
+	return mapping
+}
+
+// return tileset or default one if it's empty
+func defaultTileset(tileset string) string {
+	if tileset == "" {
+		return DefaultTileset
+	}
+
+	return tileset
+}
+
+// check size of map before its tiles are decoded, both sides must be positive and not bigger than max side.
+// return error if map is empty or too big
+func checkSize(width, height, maxSide int) error {
+	if maxSide == 0 {
+		maxSide = config.DefaultDimensionMax
+	}
+
+	if (width < 1) || (height < 1) {
+		return fmt.Errorf("map size %dx%d must be positive", width, height)
+	}
+
+	if (width > maxSide) || (height > maxSide) {
+		return fmt.Errorf("map size %dx%d is bigger than %dx%d", width, height, maxSide, maxSide)
+	}
+
+	return nil
+}
+
+// convert global tile ids of layer into level data.
+// return level data or error with row and column (1-based) of tile which isn't mapped
+func (mapping MappingType) toData(gids []uint32, width, height, maxSide int) (data [][]int, err error) {
+	err = checkSize(width, height, maxSide)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(gids) != width*height {
+		return nil, fmt.Errorf("layer has %d tiles, but map size is %dx%d", len(gids), width, height)
+	}
+
+	data = make([][]int, height)
+	for y := range data {
+		data[y] = make([]int, width)
+
+		for x := range data[y] {
+			var (
+				gid   int
+				point int
+				ok    bool
+			)
+
+			gid = int(gids[y*width+x] &^ flipFlags)
+
+			point, ok = mapping[gid]
+			if !ok {
+				// empty tile is open one, if mapping doesn't say another
+				if gid != 0 {
+					return nil, fmt.Errorf("tile %d in row %d, column %d is not mapped to level point", gid, y+1, x+1)
+				}
+
+				point = analyze.OpenTilePoint
+			}
+
+			data[y][x] = point
+		}
+	}
+
+	return data, nil
+}
+
+// convert level data into global tile ids, lines are padded by empty tiles up to the longest line.
+// each point gets the smallest tile id which is mapped to it, empty tile is used only if point has no other one.
+// return tile ids, width and height of map
+func (mapping MappingType) toGids(data [][]int) (gids []uint32, width, height int, err error) {
+	var (
+		tiles map[int]int
+		ids   []int
+	)
+
+	// reverse mapping, ids are sorted to make result stable
+	for gid := range mapping {
+		ids = append(ids, gid)
+	}
+
+	sort.Ints(ids)
+
+	tiles = make(map[int]int)
+	for _, gid := range ids {
+		if current, ok := tiles[mapping[gid]]; !ok || (current == 0) {
+			tiles[mapping[gid]] = gid
+		}
+	}
+
+	for _, line := range data {
+		if len(line) > width {
+			width = len(line)
+		}
+	}
+
+	height = len(data)
+	gids = make([]uint32, width*height)
+
+	for y, line := range data {
+		for x, point := range line {
+			var (
+				gid int
+				ok  bool
+			)
+
+			gid, ok = tiles[point]
+			if !ok {
+				return nil, 0, 0, fmt.Errorf("point %d in row %d, column %d has no tile in mapping", point, y+1, x+1)
+			}
+
+			gids[y*width+x] = uint32(gid)
+		}
+	}
+
+	return gids, width, height, nil
+}
+
+// decode layer data by its encoding: csv, base64 (optionally compressed by zlib or gzip), size is count of tiles
+// of layer, decompressed data can't be longer.
+// return global tile ids
+func decodeGids(encoding, compression, text string, size int) (gids []uint32, err error) {
+	var (
+		raw []byte
+	)
+
+	switch encoding {
+	case "csv":
+		for _, item := range strings.FieldsFunc(text, func(r rune) bool { return (r == ',') || (r == '\n') || (r == '\r') || (r == ' ') || (r == '\t') }) {
+			var (
+				gid uint64
+			)
+
+			gid, err = strconv.ParseUint(item, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid tile id %q in csv layer data", item)
+			}
+
+			gids = append(gids, uint32(gid))
+		}
+
+		return gids, nil
+
+	case "base64":
+		raw, err = base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 layer data: %w", err)
+		}
+
+		raw, err = decompress(compression, raw, int64(size)*4)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(raw)%4 != 0 {
+			return nil, errors.New("base64 layer data is not a list of 32-bit tile ids")
+		}
+
+		// tile ids are little-endian unsigned 32-bit integers
+		gids = make([]uint32, len(raw)/4)
+		for i := range gids {
+			gids[i] = uint32(raw[i*4]) | uint32(raw[i*4+1])<<8 | uint32(raw[i*4+2])<<16 | uint32(raw[i*4+3])<<24
+		}
+
+		return gids, nil
+	}
+
+	return nil, fmt.Errorf("layer data encoding %q is not supported, expected csv or base64", encoding)
+}
+
+// decompress base64 layer data, reading stops after limit bytes, so data bomb can't exhaust memory.
+// return raw data or error if it's longer than limit
+func decompress(compression string, raw []byte, limit int64) (data []byte, err error) {
+	var (
+		reader io.Reader
+	)
+
+	switch compression {
+	case "":
+		return raw, nil
+
+	case "zlib":
+		reader, err = zlib.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid zlib layer data: %w", err)
+		}
+
+	case "gzip":
+		reader, err = gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip layer data: %w", err)
+		}
+
+	default:
+		return nil, fmt.Errorf("layer data compression %q is not supported, expected zlib or gzip", compression)
+	}
+
+	data, err = ioutil.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s layer data: %w", compression, err)
+	}
+
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s layer data is longer than %d bytes of map size", compression, limit)
+	}
+
+	return data, nil
+}
+
+// convert list of tile ids into csv text, each row of map is on its own line
+func encodeCSV(gids []uint32, width int) string {
+	var (
+		builder strings.Builder
+	)
+
+	for i, gid := range gids {
+		if (i > 0) && (i%width == 0) {
+			builder.WriteString(",\n")
+		} else if i > 0 {
+			builder.WriteString(",")
+		}
+
+		builder.WriteString(strconv.FormatUint(uint64(gid), 10))
+	}
+
+	return builder.String()
+}
+
+// fill level's creator, game and number from map properties, number must be integer.
+// return level meta
+func metaFromProperties(properties map[string]string) (meta codec.MetaType, err error) {
+	meta.Creator = properties[propertyCreator]
+	meta.Game = properties[propertyGame]
+
+	if properties[propertyLevel] != "" {
+		meta.Level, err = strconv.ParseInt(properties[propertyLevel], 10, 64)
+		if err != nil {
+			return meta, fmt.Errorf("map property %s must be integer", propertyLevel)
+		}
+	}
+
+	return meta, nil
+}
@@ -0,0 +1,207 @@
+package tiled
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"greenjade/codec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const tmxLevel = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" width="4" height="3" tilewidth="32" tileheight="32" infinite="0">
+ <properties>
+  <property name="creator" value="artist"/>
+  <property name="game" value="dungeon"/>
+  <property name="level" type="int" value="7"/>
+ </properties>
+ <tileset firstgid="1" source="labyrinth.tsx"/>
+ <layer id="1" name="ground" width="4" height="3">
+  <data%s>%s</data>
+ </layer>
+ <layer id="2" name="decoration" width="4" height="3">
+  <data encoding="csv">0,0,0,0,0,0,0,0,0,0,0,0</data>
+ </layer>
+</map>
+`
+
+// walls (2), alternative exit tile (7), hero tile flipped horizontally (5) and empty tile (0)
+var tmxGids = []uint32{2, 2, 7, 2, 2, 5 | 0x80000000, 0, 2, 2, 2, 2, 2}
+
+var tmxData = [][]int{{1, 1, 5, 1}, {1, 4, 0, 1}, {1, 1, 1, 1}}
+
+func TestImportTMX(t *testing.T) {
+	var (
+		err error
+
+		raw     bytes.Buffer
+		writer  *zlib.Writer
+		tiles   strings.Builder
+		csv     []string
+		mapping MappingType
+	)
+
+	writer = zlib.NewWriter(&raw)
+
+	err = binary.Write(writer, binary.LittleEndian, tmxGids)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, gid := range tmxGids {
+		fmt.Fprintf(&tiles, `<tile gid="%d"/>`, gid)
+		csv = append(csv, fmt.Sprint(gid))
+	}
+
+	mapping = DefaultMapping()
+	mapping[7] = 5
+
+	for name, input := range map[string]string{
+		"csv":    fmt.Sprintf(tmxLevel, ` encoding="csv"`, "\n"+strings.Join(csv, ",")+"\n"),
+		"base64": fmt.Sprintf(tmxLevel, ` encoding="base64" compression="zlib"`, base64.StdEncoding.EncodeToString(raw.Bytes())),
+		"xml":    fmt.Sprintf(tmxLevel, "", tiles.String()),
+	} {
+		var (
+			meta codec.MetaType
+			data [][]int
+		)
+
+		meta, data, err = TMXType{Mapping: mapping}.DecodeLevel([]byte(input))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(data, tmxData) {
+			t.Errorf("%s: expected %v, got %v", name, tmxData, data)
+		}
+
+		if (meta.Creator != "artist") || (meta.Game != "dungeon") || (meta.Level != 7) {
+			t.Errorf("%s: unexpected level meta %q %q %d", name, meta.Creator, meta.Game, meta.Level)
+		}
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	var (
+		err error
+
+		raw    bytes.Buffer
+		writer *zlib.Writer
+		tmx    TMXType
+		tmj    JSONType
+	)
+
+	tmx = TMXType{Mapping: DefaultMapping()}
+	tmj = JSONType{Mapping: DefaultMapping()}
+
+	_, _, err = tmx.DecodeLevel([]byte(fmt.Sprintf(tmxLevel, ` encoding="csv"`, "2,2,7,2,2,5,0,2,2,2,2,2")))
+	if (err == nil) || (err.Error() != "tile 7 in row 1, column 3 is not mapped to level point") {
+		t.Errorf("expected error for unmapped tile, got %v", err)
+	}
+
+	_, _, err = tmx.DecodeLevel([]byte(fmt.Sprintf(tmxLevel, ` encoding="csv"`, "2,2,2")))
+	if err == nil {
+		t.Error("expected error for layer of wrong size")
+	}
+
+	_, _, err = tmx.DecodeLevel([]byte(`<map infinite="1"></map>`))
+	if err == nil {
+		t.Error("expected error for infinite map")
+	}
+
+	_, _, err = tmj.DecodeLevel([]byte(`{"infinite":false,"layers":[{"type":"objectgroup"}]}`))
+	if err == nil {
+		t.Error("expected error for map without tile layer")
+	}
+
+	_, _, err = tmj.DecodeLevel([]byte(`{"layers":[{"type":"tilelayer","width":0,"height":1000000000,"data":[]}]}`))
+	if (err == nil) || (err.Error() != "map size 0x1000000000 must be positive") {
+		t.Errorf("expected error for map of zero width, got %v", err)
+	}
+
+	_, _, err = TMXType{Mapping: DefaultMapping(), MaxSide: 3}.DecodeLevel([]byte(fmt.Sprintf(tmxLevel, ` encoding="csv"`, "")))
+	if (err == nil) || (err.Error() != "map size 4x3 is bigger than 3x3") {
+		t.Errorf("expected error for too big map, got %v", err)
+	}
+
+	// max side of actual constraints replaces one of registration
+	_, _, err = codec.WithMaxSide(TMXType{Mapping: DefaultMapping(), MaxSide: 10}, 3).(codec.MetaCodecType).DecodeLevel([]byte(fmt.Sprintf(tmxLevel, ` encoding="csv"`, "")))
+	if (err == nil) || (err.Error() != "map size 4x3 is bigger than 3x3") {
+		t.Errorf("expected error for map bigger than actual max side, got %v", err)
+	}
+
+	_, _, err = codec.WithMaxSide(JSONType{Mapping: DefaultMapping(), MaxSide: 10}, 1).(codec.MetaCodecType).DecodeLevel([]byte(`{"layers":[{"type":"tilelayer","width":2,"height":1,"data":[1,1]}]}`))
+	if (err == nil) || (err.Error() != "map size 2x1 is bigger than 1x1") {
+		t.Errorf("expected error for json map bigger than actual max side, got %v", err)
+	}
+
+	// 1MB of zeros is packed into few bytes, but layer has only 12 tiles
+	writer = zlib.NewWriter(&raw)
+	_, _ = writer.Write(make([]byte, 1<<20))
+	_ = writer.Close()
+
+	_, _, err = tmx.DecodeLevel([]byte(fmt.Sprintf(tmxLevel, ` encoding="base64" compression="zlib"`, base64.StdEncoding.EncodeToString(raw.Bytes()))))
+	if (err == nil) || (err.Error() != "zlib layer data is longer than 48 bytes of map size") {
+		t.Errorf("expected error for compressed data longer than map, got %v", err)
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	var (
+		err error
+
+		meta, resultMeta codec.MetaType
+		data, result     [][]int
+		output           []byte
+	)
+
+	meta = codec.MetaType{Creator: "designer", Game: "labyrinth", Level: 3}
+	data = [][]int{
+		{1, 1, 5, 1},
+		{1, 2, 3, 1},
+		{1, 4, 0, 1},
+		{1, 1, 1, 1},
+	}
+
+	for _, mapCodec := range []codec.MetaCodecType{
+		TMXType{Mapping: DefaultMapping(), Tileset: DefaultTileset},
+		JSONType{Mapping: DefaultMapping(), Tileset: DefaultTileset},
+	} {
+		output, err = mapCodec.EncodeLevel(meta, data)
+		if err != nil {
+			t.Errorf("%s: %v", mapCodec.Name(), err)
+			continue
+		}
+
+		resultMeta, result, err = mapCodec.DecodeLevel(output)
+		if err != nil {
+			t.Errorf("%s: %v\n%s", mapCodec.Name(), err, output)
+			continue
+		}
+
+		if (resultMeta != meta) || !reflect.DeepEqual(result, data) {
+			t.Errorf("%s: expected %+v %v, got %+v %v", mapCodec.Name(), meta, data, resultMeta, result)
+		}
+	}
+
+	// several tiles of the same point are exported as the smallest one
+	output, err = TMXType{Mapping: MappingType{2: 1, 10: 0, 1: 1, 3: 2, 4: 3, 5: 4, 6: 5}}.EncodeLevel(meta, data)
+	if (err != nil) || !strings.Contains(string(output), "1,1,6,1,\n1,3,4,1,\n1,5,10,1,") {
+		t.Errorf("unexpected tmx export: %v\n%s", err, output)
+	}
+
+	_, err = JSONType{Mapping: MappingType{1: 1}}.EncodeLevel(meta, data)
+	if err == nil {
+		t.Error("expected error for point without tile")
+	}
+}
@@ -0,0 +1,197 @@
+package tiled
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"greenjade/codec"
+	"strconv"
+)
+
+const (
+	MediaTypeTMX = "application/x-tiled+xml" // media type of Tiled map in xml format
+)
+
+// Tiled map in xml format, only fields which are needed for level
+type tmxMapType struct {
+	XMLName      xml.Name          `xml:"map"`
+	Version      string            `xml:"version,attr"`
+	Orientation  string            `xml:"orientation,attr"`
+	RenderOrder  string            `xml:"renderorder,attr"`
+	Width        int               `xml:"width,attr"`
+	Height       int               `xml:"height,attr"`
+	TileWidth    int               `xml:"tilewidth,attr"`
+	TileHeight   int               `xml:"tileheight,attr"`
+	Infinite     int               `xml:"infinite,attr"`
+	NextLayerId  int               `xml:"nextlayerid,attr,omitempty"`
+	NextObjectId int               `xml:"nextobjectid,attr,omitempty"`
+	Properties   []tmxPropertyType `xml:"properties>property"`
+	Tilesets     []tmxTilesetType  `xml:"tileset"`
+	Layers       []tmxLayerType    `xml:"layer"`
+}
+
+type tmxPropertyType struct {
+	Name  string `xml:"name,attr"`
+	Type  string `xml:"type,attr,omitempty"`
+	Value string `xml:"value,attr"`
+}
+
+type tmxTilesetType struct {
+	FirstGid int    `xml:"firstgid,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+type tmxLayerType struct {
+	Id     int         `xml:"id,attr"`
+	Name   string      `xml:"name,attr"`
+	Width  int         `xml:"width,attr"`
+	Height int         `xml:"height,attr"`
+	Data   tmxDataType `xml:"data"`
+}
+
+type tmxDataType struct {
+	Encoding    string        `xml:"encoding,attr,omitempty"`
+	Compression string        `xml:"compression,attr,omitempty"`
+	Text        string        `xml:",chardata"`
+	CSV         string        `xml:",innerxml"` // csv text of exported map, it's written without escaping of line breaks
+	Tiles       []tmxTileType `xml:"tile"`
+}
+
+type tmxTileType struct {
+	Gid uint32 `xml:"gid,attr"`
+}
+
+// codec of Tiled map in xml format (.tmx), level is the first tile layer of map.
+// exported map refers to external tileset file, tile ids of mapping must be ids of this tileset
+type TMXType struct {
+	Mapping MappingType
+	Tileset string
+	MaxSide int // max width and height of map, zero means default max dimension
+}
+
+func (TMXType) Name() string {
+	return "tmx"
+}
+
+func (TMXType) MediaType() string {
+	return MediaTypeTMX
+}
+
+// return copy of codec with another max side of map
+func (obj TMXType) WithMaxSide(maxSide int) codec.CodecType {
+	obj.MaxSide = maxSide
+	return obj
+}
+
+func (obj TMXType) Decode(input []byte) (data [][]int, err error) {
+	_, data, err = obj.DecodeLevel(input)
+	return data, err
+}
+
+func (obj TMXType) Encode(data [][]int) (output []byte, err error) {
+	return obj.EncodeLevel(codec.MetaType{}, data)
+}
+
+// read level data from the first tile layer and level's creator, game and number from map properties.
+// return level meta and data
+func (obj TMXType) DecodeLevel(input []byte) (meta codec.MetaType, data [][]int, err error) {
+	var (
+		tmx        tmxMapType
+		layer      tmxLayerType
+		gids       []uint32
+		properties map[string]string
+	)
+
+	err = xml.Unmarshal(input, &tmx)
+	if err != nil {
+		return meta, nil, fmt.Errorf("invalid tmx map: %w", err)
+	}
+
+	if tmx.Infinite != 0 {
+		return meta, nil, errors.New("infinite maps are not supported")
+	}
+
+	if len(tmx.Layers) == 0 {
+		return meta, nil, errors.New("map has no tile layer")
+	}
+
+	layer = tmx.Layers[0]
+
+	err = checkSize(layer.Width, layer.Height, obj.MaxSide)
+	if err != nil {
+		return meta, nil, err
+	}
+
+	// layer without encoding keeps tiles as xml elements
+	if layer.Data.Encoding == "" {
+		for _, tile := range layer.Data.Tiles {
+			gids = append(gids, tile.Gid)
+		}
+	} else {
+		gids, err = decodeGids(layer.Data.Encoding, layer.Data.Compression, layer.Data.Text, layer.Width*layer.Height)
+		if err != nil {
+			return meta, nil, err
+		}
+	}
+
+	data, err = obj.Mapping.toData(gids, layer.Width, layer.Height, obj.MaxSide)
+	if err != nil {
+		return meta, nil, err
+	}
+
+	properties = make(map[string]string)
+	for _, property := range tmx.Properties {
+		properties[property.Name] = property.Value
+	}
+
+	meta, err = metaFromProperties(properties)
+
+	return meta, data, err
+}
+
+// write level data as single csv tile layer, level's creator, game and number are map properties.
+// return tmx document
+func (obj TMXType) EncodeLevel(meta codec.MetaType, data [][]int) (output []byte, err error) {
+	var (
+		tmx           tmxMapType
+		gids          []uint32
+		width, height int
+	)
+
+	gids, width, height, err = obj.Mapping.toGids(data)
+	if err != nil {
+		return nil, err
+	}
+
+	tmx = tmxMapType{
+		Version:      "1.10",
+		Orientation:  "orthogonal",
+		RenderOrder:  "right-down",
+		Width:        width,
+		Height:       height,
+		TileWidth:    DefaultTileSize,
+		TileHeight:   DefaultTileSize,
+		NextLayerId:  2,
+		NextObjectId: 1,
+		Properties: []tmxPropertyType{
+			{Name: propertyCreator, Value: meta.Creator},
+			{Name: propertyGame, Value: meta.Game},
+			{Name: propertyLevel, Type: "int", Value: strconv.FormatInt(meta.Level, 10)},
+		},
+		Tilesets: []tmxTilesetType{{FirstGid: 1, Source: obj.Tileset}},
+		Layers: []tmxLayerType{{
+			Id:     1,
+			Name:   "level",
+			Width:  width,
+			Height: height,
+			Data:   tmxDataType{Encoding: "csv", CSV: "\n" + encodeCSV(gids, width) + "\n"},
+		}},
+	}
+
+	output, err = xml.MarshalIndent(tmx, "", " ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), append(output, '\n')...), nil
+}